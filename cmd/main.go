@@ -5,12 +5,16 @@ import (
 	"log/slog"
 	"microsoft-apps-exporter/internal/api"
 	"microsoft-apps-exporter/internal/api/webhook"
+	"microsoft-apps-exporter/internal/configuration"
 	"microsoft-apps-exporter/internal/database"
 	"microsoft-apps-exporter/internal/logging"
+	"microsoft-apps-exporter/internal/metrics"
 	"microsoft-apps-exporter/internal/sync"
+	"microsoft-apps-exporter/internal/tracing"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 func main() {
@@ -22,8 +26,29 @@ func main() {
 	logging.ConfigureSlog()
 	slog.Info("Application starting")
 
+	// Reject an invalid resources.yaml before anything is synced.
+	if err := configuration.GetConfig().Validate(); err != nil {
+		slog.Error("Invalid configuration", "exception", err)
+		return
+	}
+
+	// Install the tracer provider before any span is started.
+	shutdownTracing, err := tracing.Setup(ctx)
+	if err != nil {
+		slog.Error("Failed to set up tracing", "exception", err)
+		return
+	}
+	defer func() {
+		// Flush the remaining spans even though the main context is already cancelled.
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			slog.Error("Failed to shut down tracing", "exception", err)
+		}
+	}()
+
 	// Establish database connection.
-	db, err := database.NewDatabase()
+	db, err := database.NewDatabase(ctx)
 	if err != nil {
 		slog.Error("Failed to create Database instance", "exception", err)
 		return
@@ -31,11 +56,12 @@ func main() {
 	defer db.Close()
 
 	// Initiate API client.
-	graphHelper, err := api.NewGraphHelper(ctx)
+	graphHelper, err := api.NewGraphHelper(db)
 	if err != nil {
 		slog.Error("Failed to create GraphHelper instance", "exception", err)
 		return
 	}
+	metrics.Registry.MustRegister(api.NewSubscriptionExpiryCollector(graphHelper))
 
 	syncer := sync.NewSyncer(graphHelper, db)
 
@@ -45,19 +71,36 @@ func main() {
 		slog.Error("Failed to run webhook server", "exception", err)
 		return
 	}
-	defer webhookServer.Shutdown(ctx)
+
+	// Running syncs outlive the main context and are drained on shutdown.
+	syncer.Start(ctx)
+	defer func() {
+		// Stop receiving notifications first, then let the syncs finish within the same grace period.
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), configuration.GetConfig().SHUTDOWN_GRACE_PERIOD)
+		defer cancel()
+		webhookServer.Shutdown(shutdownCtx)
+		syncer.Shutdown(shutdownCtx)
+	}()
+
+	// Queue the syncs left unfinished by the previous shutdown.
+	if err := syncer.ResumePending(ctx); err != nil {
+		slog.Error("Failed to resume pending syncs", "exception", err)
+	}
 
 	// Ensures subscribed to Microsoft Graph API notificaitions.
-	if _, err := graphHelper.EnsureResourcesSubscriptions(); err != nil {
+	if _, err := graphHelper.EnsureResourcesSubscriptions(ctx); err != nil {
 		slog.Error("Failed to validate MS Graph API subscriptions", "exception", err)
 		return
 	}
 
-	// Start synchronization.
-	if err := syncer.SyncResources(); err != nil {
-		slog.Error("Failed to sync resources", "exception", err)
-		return
-	}
+	// Renew subscriptions before they expire.
+	go api.NewSubscriptionRenewer(graphHelper).Run(ctx)
+
+	// Queue the sync of every list, the lists failing to sync are retried later.
+	syncer.SyncResources(ctx)
+
+	// Periodically reconcile lists in case change notifications were missed.
+	go sync.NewScheduler(syncer).Run(ctx)
 
 	<-stop
 }
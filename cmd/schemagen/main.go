@@ -0,0 +1,186 @@
+// Command schemagen generates the tables of the configured SharePoint lists from their column definitions.
+// It writes a goose migration per list table, or applies the DDL to the database with -apply.
+// Tables that already have a migration, or already exist when applying, are skipped.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"microsoft-apps-exporter/internal/api"
+	"microsoft-apps-exporter/internal/configuration"
+	"microsoft-apps-exporter/internal/database"
+	"microsoft-apps-exporter/internal/logging"
+	"microsoft-apps-exporter/internal/models"
+	"microsoft-apps-exporter/internal/schema"
+	"microsoft-apps-exporter/internal/transform"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func main() {
+	dir := flag.String("dir", "migrations", "directory of the goose migrations")
+	apply := flag.Bool("apply", false, "create the missing tables in the database instead of writing migrations")
+	listID := flag.String("list", "", "only generate the table of the list with this ID")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	logging.ConfigureSlog()
+	if err := run(ctx, *dir, *apply, *listID); err != nil {
+		slog.Error("Failed to generate schema", "exception", err, "operation", "schema")
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, dir string, apply bool, listID string) error {
+	config := configuration.GetConfig()
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	resource := config.Sharepoint
+	if resource == nil || len(resource.Lists) == 0 {
+		return fmt.Errorf("no SharePoint lists configured")
+	}
+
+	lists := resource.Lists
+	if listID != "" {
+		list, ok := findList(lists, listID)
+		if !ok {
+			return fmt.Errorf("list %s is not configured", listID)
+		}
+		lists = []models.ListReference{list}
+	}
+
+	graphHelper, err := api.NewGraphHelper(nil)
+	if err != nil {
+		return fmt.Errorf("failed to create GraphHelper instance: %w", err)
+	}
+
+	var db *database.Database
+	if apply {
+		db, err = database.NewDatabase(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create Database instance: %w", err)
+		}
+		defer db.Close()
+	}
+
+	now := time.Now()
+	failed := 0
+	for i, list := range lists {
+		columns, err := graphHelper.GetListColumns(ctx, list.SiteID, list.ListID)
+		if err != nil {
+			slog.Error("Failed to retrieve list columns", "site_id", list.SiteID, "list_id", list.ListID,
+				"exception", err, "operation", "schema")
+			failed++
+			continue
+		}
+
+		transforms, err := transform.NewPipeline(list)
+		if err != nil {
+			slog.Error("Invalid list transforms", "site_id", list.SiteID, "list_id", list.ListID,
+				"exception", err, "operation", "schema")
+			failed++
+			continue
+		}
+
+		table, err := schema.BuildTable(list, transforms, columns)
+		if err != nil {
+			slog.Error("Failed to build list table", "site_id", list.SiteID, "list_id", list.ListID,
+				"exception", err, "operation", "schema")
+			failed++
+			continue
+		}
+
+		if apply {
+			err = applyTable(ctx, db, table)
+		} else {
+			// Successive migrations keep the order of the configured lists
+			err = writeMigration(dir, table, now.Add(time.Duration(i)*time.Second))
+		}
+		if err != nil {
+			slog.Error("Failed to create list table", "list_id", list.ListID, "database_table", table.Name,
+				"exception", err, "operation", "schema")
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d lists failed", failed, len(lists))
+	}
+	return nil
+}
+
+// applyTable creates the table unless it exists.
+func applyTable(ctx context.Context, db *database.Database, table schema.Table) error {
+	exists, err := db.TableExists(ctx, table.Name)
+	if err != nil {
+		return fmt.Errorf("failed to check table: %w", err)
+	}
+	if exists {
+		slog.Info("Table already exists, skipped", "database_table", table.Name, "operation", "schema")
+		return nil
+	}
+
+	if err := db.ApplySchema(ctx, table.CreateStatement()); err != nil {
+		return fmt.Errorf("failed to apply DDL: %w", err)
+	}
+	slog.Info("Table created", "database_table", table.Name, "operation", "schema")
+	return nil
+}
+
+// writeMigration writes the migration creating the table unless a migration of the directory already creates it.
+func writeMigration(dir string, table schema.Table, now time.Time) error {
+	existing, err := findMigration(dir, table.Name)
+	if err != nil {
+		return fmt.Errorf("failed to read migrations: %w", err)
+	}
+	if existing != "" {
+		slog.Info("Migration already exists, skipped", "database_table", table.Name, "file", existing, "operation", "schema")
+		return nil
+	}
+
+	path := filepath.Join(dir, table.MigrationFileName(now))
+	if err := os.WriteFile(path, []byte(table.Migration()), 0o644); err != nil {
+		return fmt.Errorf("failed to write migration: %w", err)
+	}
+	slog.Info("Migration written", "database_table", table.Name, "file", path, "operation", "schema")
+	return nil
+}
+
+// findMigration returns the path of the migration creating the table, or an empty string if there is none.
+func findMigration(dir, table string) (string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return "", err
+	}
+
+	statement := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s ", table)
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		if strings.Contains(string(content), statement) {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+func findList(lists []models.ListReference, listID string) (models.ListReference, bool) {
+	for _, list := range lists {
+		if list.ListID == listID {
+			return list, true
+		}
+	}
+	return models.ListReference{}, false
+}
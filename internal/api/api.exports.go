@@ -5,30 +5,106 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"microsoft-apps-exporter/internal/models"
+	"net/http"
+	"time"
 
+	khttp "github.com/microsoft/kiota-http-go"
 	gmodels "github.com/microsoftgraph/msgraph-sdk-go/models"
 	graphsites "github.com/microsoftgraph/msgraph-sdk-go/sites"
 )
 
 var (
-	SubscriptionUpdateExpiry    = subscriptionUpdateExpiry
 	WebhookSubscriptionEndpoint = webhookSubscriptionEndpoint
 )
 
-func (g *GraphHelper) RequestList(siteID, listID string) (gmodels.Listable, error) {
-	return g.requestList(siteID, listID)
+func (g *GraphHelper) RequestList(ctx context.Context, siteID, listID string) (gmodels.Listable, error) {
+	return g.requestList(ctx, siteID, listID)
 }
 
-func (g *GraphHelper) RequestListItemsWithDelta(siteID, listID string, deltaLink *string,
-	options *graphsites.ItemListsItemItemsDeltaRequestBuilderGetRequestConfiguration) (*string, []gmodels.ListItemable, error) {
-	return g.requestListItemsWithDelta(siteID, listID, deltaLink, options)
+// errStopPaging stops forEachDeltaPage without failing the query.
+var errStopPaging = errors.New("stop paging")
+
+// RequestListItemsWithDelta retrieves the list items of every page, or of the pages covering the Top option,
+// along with the delta link. It also returns the number of requested pages.
+func (g *GraphHelper) RequestListItemsWithDelta(ctx context.Context, siteID, listID string, deltaLink *string,
+	options *graphsites.ItemListsItemItemsDeltaRequestBuilderGetRequestConfiguration) (*string, []gmodels.ListItemable, int, error) {
+	var topLimit int
+	if options != nil && options.QueryParameters.Top != nil {
+		topLimit = int(*options.QueryParameters.Top)
+	}
+
+	var (
+		newDeltaLink *string
+		listItems    []gmodels.ListItemable
+	)
+	pages, err := g.forEachDeltaPage(ctx, siteID, listID, deltaLink, options, func(response graphsites.ItemListsItemItemsDeltaGetResponseable) error {
+		listItems = append(listItems, response.GetValue()...)
+		newDeltaLink = response.GetOdataDeltaLink()
+
+		if newDeltaLink == nil && topLimit > 0 && len(listItems) >= topLimit {
+			return errStopPaging
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopPaging) {
+		return nil, nil, pages, err
+	}
+
+	return newDeltaLink, listItems, pages, nil
 }
 
 func (g *GraphHelper) ParseListItemResponse(itemResponse gmodels.ListItemable) (*models.ListItem, error) {
 	return g.parseListItemResponse(itemResponse)
 }
 
+func ParseColumnDefinition(columnResponse gmodels.ColumnDefinitionable) models.ColumnDefinition {
+	return parseColumnDefinition(columnResponse)
+}
+
 func DeserializeFields(serializedFields []byte) (models.ListItemMappedFields, error) {
 	return deserializeFields(serializedFields)
 }
+
+func NewClientState() (string, error) {
+	return newClientState()
+}
+
+func (g *GraphHelper) TrackSubscription(subscriptionID, resource, clientState string) {
+	g.trackSubscription(context.Background(), subscriptionID, trackedSubscription{Resource: resource, ClientState: clientState})
+}
+
+func (g *GraphHelper) AdoptClientState(ctx context.Context, subscription gmodels.Subscriptionable) bool {
+	return g.adoptClientState(ctx, subscription)
+}
+
+func (g *GraphHelper) TrackSubscriptionExpiry(subscriptionID, resource string, expirationDateTime time.Time) {
+	g.trackSubscription(context.Background(), subscriptionID, trackedSubscription{Resource: resource, ExpirationDateTime: expirationDateTime})
+}
+
+func DueForRenewal(expirationDateTime, now time.Time, margin time.Duration) bool {
+	return dueForRenewal(expirationDateTime, now, margin)
+}
+
+func (g *GraphHelper) IsSubscriptionOwned(subscription gmodels.Subscriptionable) bool {
+	return g.isSubscriptionOwned(subscription)
+}
+
+func NewRetryHandler(maxRetries int, baseDelay, maxDelay, budget time.Duration,
+	sleep func(ctx context.Context, delay time.Duration) error) khttp.Middleware {
+	return &retryHandler{MaxRetries: maxRetries, BaseDelay: baseDelay, MaxDelay: maxDelay, Budget: budget, sleep: sleep}
+}
+
+func RetryAfter(response *http.Response, now time.Time) (time.Duration, bool) {
+	return retryAfter(response, now)
+}
+
+func WithOperation(ctx context.Context, operation string) context.Context {
+	return withOperation(ctx, operation)
+}
+
+func NewMetricsHandler() khttp.Middleware {
+	return metricsHandler{}
+}
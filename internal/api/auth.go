@@ -9,6 +9,7 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	auth "github.com/microsoft/kiota-authentication-azure-go"
 	msgraphsdk "github.com/microsoftgraph/msgraph-sdk-go"
+	msgraphgocore "github.com/microsoftgraph/msgraph-sdk-go-core"
 )
 
 // InitializeGraphForUserAuth sets up authentication for Microsoft Graph API.
@@ -34,17 +35,17 @@ func (g *GraphHelper) AuthenticateGraphHelper() error {
 		return fmt.Errorf("failed to create authentication provider: %w", err)
 	}
 
-	// Create request adapter
-	g.Adapter, err = msgraphsdk.NewGraphRequestAdapter(authProvider)
+	// Create request adapter retrying throttled and transiently failing requests
+	// The default options report the service and SDK versions in the telemetry header
+	clientOptions := msgraphsdk.GetDefaultClientOptions()
+	httpClient := msgraphgocore.GetDefaultClient(&clientOptions, graphMiddlewares(&clientOptions)...)
+	g.Adapter, err = msgraphsdk.NewGraphRequestAdapterWithParseNodeFactoryAndSerializationWriterFactoryAndHttpClient(authProvider, nil, nil, httpClient)
 	if err != nil {
 		return fmt.Errorf("failed to create Graph request adapter: %w", err)
 	}
 
 	// Initialize Graph client
-	g.Client, err = msgraphsdk.NewGraphServiceClientWithCredentials(g.Credential, g.AppScopes)
-	if err != nil {
-		return fmt.Errorf("failed to initialize Graph client: %w", err)
-	}
+	g.Client = msgraphsdk.NewGraphServiceClient(g.Adapter)
 
 	return nil
 }
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"microsoft-apps-exporter/internal/configuration"
+
+	gmodels "github.com/microsoftgraph/msgraph-sdk-go/models"
+)
+
+const clientStateBytes = 32
+
+// newClientState returns the configured clientState secret or generates a random one.
+func newClientState() (string, error) {
+	config := configuration.GetConfig()
+	if config.WEBHOOK_CLIENT_STATE != "" {
+		return config.WEBHOOK_CLIENT_STATE, nil
+	}
+
+	secret := make([]byte, clientStateBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate client state: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// VerifyClientState reports whether the clientState received with a notification matches the
+// secret of its subscription. Notifications of unknown subscriptions are verified against
+// the configured secret, if any.
+func (g *GraphHelper) VerifyClientState(subscriptionID, clientState string) bool {
+	expected := configuration.GetConfig().WEBHOOK_CLIENT_STATE
+	if tracked, ok := g.trackedSubscription(subscriptionID); ok {
+		expected = tracked.ClientState
+	}
+
+	if expected == "" || clientState == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(clientState)) == 1
+}
+
+// adoptClientState registers the clientState of an existing subscription.
+// It reports false when the secret is unknown or outdated and the subscription has to be recreated.
+func (g *GraphHelper) adoptClientState(ctx context.Context, subscription gmodels.Subscriptionable) bool {
+	subscriptionID := *subscription.GetId()
+	configured := configuration.GetConfig().WEBHOOK_CLIENT_STATE
+
+	if tracked, ok := g.trackedSubscription(subscriptionID); ok && tracked.ClientState != "" {
+		if configured != "" && tracked.ClientState != configured {
+			return false
+		}
+		g.trackSubscription(ctx, subscriptionID, newTrackedSubscription(subscription, tracked.ClientState))
+		return true
+	}
+
+	actual := safeString(subscription.GetClientState())
+	if actual == "" {
+		slog.Info("Subscription client state is unknown", "subscription_id", subscriptionID, "operation", "subscriptions")
+		return false
+	}
+	if configured != "" && actual != configured {
+		slog.Info("Subscription client state differs from configured one", "subscription_id", subscriptionID, "operation", "subscriptions")
+		return false
+	}
+
+	g.trackSubscription(ctx, subscriptionID, newTrackedSubscription(subscription, actual))
+	return true
+}
@@ -3,6 +3,10 @@ package api
 import (
 	"context"
 	"fmt"
+	"microsoft-apps-exporter/internal/configuration"
+	"microsoft-apps-exporter/internal/database"
+	"sync"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	msgraphsdk "github.com/microsoftgraph/msgraph-sdk-go"
@@ -10,19 +14,34 @@ import (
 
 // GraphClient handles authentication and communication with Microsoft Graph API.
 type GraphHelper struct {
-	Ctx        context.Context
 	Credential *azidentity.ClientSecretCredential
 	Adapter    *msgraphsdk.GraphRequestAdapter
 	Client     *msgraphsdk.GraphServiceClient
 	AppScopes  []string
+	Database   *database.Database // Optional store of the managed subscriptions
+
+	RequestTimeout time.Duration // Deadline of every Graph request including its retries, none when zero
+
+	mu            sync.RWMutex
+	subscriptions map[string]trackedSubscription // Subscriptions managed by this instance, keyed by ID
 }
 
 // NewGraphClient initializes and authenticates a new GraphClient instance.
-func NewGraphHelper(ctx context.Context) (*GraphHelper, error) {
-	g := &GraphHelper{Ctx: ctx}
+// The database is optional, when nil the managed subscriptions are not persisted.
+func NewGraphHelper(db *database.Database) (*GraphHelper, error) {
+	g := &GraphHelper{Database: db, RequestTimeout: configuration.GetConfig().GRAPH_REQUEST_TIMEOUT}
 
 	if err := g.AuthenticateGraphHelper(); err != nil {
 		return nil, fmt.Errorf("failed to authenticate GraphHelper: %w", err)
 	}
 	return g, nil
 }
+
+// requestContext annotates the context with the Graph operation and bounds it by the request timeout.
+func (g *GraphHelper) requestContext(ctx context.Context, operation string) (context.Context, context.CancelFunc) {
+	ctx = withOperation(ctx, operation)
+	if g.RequestTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, g.RequestTimeout)
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"microsoft-apps-exporter/internal/metrics"
+
+	khttp "github.com/microsoft/kiota-http-go"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metricsHandler is a Graph request adapter middleware recording the latency and status code of every request.
+// It is placed after retryHandler, so each retry attempt is observed separately.
+type metricsHandler struct{}
+
+// Intercept implements khttp.Middleware.
+func (metricsHandler) Intercept(pipeline khttp.Pipeline, middlewareIndex int, req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	response, err := pipeline.Next(req, middlewareIndex)
+
+	status := "error" // Transport failure without a response
+	if err == nil {
+		status = strconv.Itoa(response.StatusCode)
+	}
+
+	operation := operationFromContext(req.Context())
+	if operation == "" {
+		operation = "unknown"
+	}
+
+	metrics.GraphRequestDuration.WithLabelValues(operation, status).Observe(time.Since(start).Seconds())
+	metrics.GraphRequests.WithLabelValues(operation, status).Inc()
+	return response, err
+}
+
+var subscriptionExpiryDesc = prometheus.NewDesc(
+	"microsoft_apps_exporter_subscription_expiry_seconds",
+	"Seconds until the Graph subscription expires, negative once expired.",
+	[]string{"subscription_id", "resource"}, nil,
+)
+
+// subscriptionExpiryCollector exposes the remaining lifetime of every tracked subscription,
+// computed at scrape time.
+type subscriptionExpiryCollector struct {
+	graph *GraphHelper
+}
+
+// NewSubscriptionExpiryCollector creates a Prometheus collector for the subscriptions tracked by the helper.
+func NewSubscriptionExpiryCollector(graph *GraphHelper) prometheus.Collector {
+	return &subscriptionExpiryCollector{graph: graph}
+}
+
+// Describe implements prometheus.Collector.
+func (c *subscriptionExpiryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- subscriptionExpiryDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *subscriptionExpiryCollector) Collect(ch chan<- prometheus.Metric) {
+	now := time.Now()
+	for subscriptionID, subscription := range c.graph.trackedSubscriptions() {
+		if subscription.ExpirationDateTime.IsZero() {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(subscriptionExpiryDesc, prometheus.GaugeValue,
+			subscription.ExpirationDateTime.Sub(now).Seconds(), subscriptionID, subscription.Resource)
+	}
+}
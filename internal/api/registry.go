@@ -0,0 +1,190 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"microsoft-apps-exporter/internal/models"
+	"time"
+
+	gmodels "github.com/microsoftgraph/msgraph-sdk-go/models"
+)
+
+// trackedSubscription is the locally known state of a subscription managed by this instance.
+// When a database is configured, it is persisted to the graph_subscriptions table.
+type trackedSubscription struct {
+	Resource                 string
+	NotificationURL          string
+	LifecycleNotificationURL string
+	ClientState              string
+	ExpirationDateTime       time.Time
+}
+
+// newTrackedSubscription builds the tracked state of a subscription returned by Graph.
+func newTrackedSubscription(subscription gmodels.Subscriptionable, clientState string) trackedSubscription {
+	return trackedSubscription{
+		Resource:                 safeString(subscription.GetResource()),
+		NotificationURL:          safeString(subscription.GetNotificationUrl()),
+		LifecycleNotificationURL: safeString(subscription.GetLifecycleNotificationUrl()),
+		ClientState:              clientState,
+		ExpirationDateTime:       safeTime(subscription.GetExpirationDateTime()),
+	}
+}
+
+// trackSubscription stores the state of a subscription.
+func (g *GraphHelper) trackSubscription(ctx context.Context, subscriptionID string, subscription trackedSubscription) {
+	g.mu.Lock()
+	if g.subscriptions == nil {
+		g.subscriptions = make(map[string]trackedSubscription)
+	}
+	g.subscriptions[subscriptionID] = subscription
+	g.mu.Unlock()
+
+	if g.Database == nil {
+		return
+	}
+	err := g.Database.SaveSubscription(ctx, models.GraphSubscription{
+		ID:                       subscriptionID,
+		Resource:                 subscription.Resource,
+		NotificationURL:          subscription.NotificationURL,
+		LifecycleNotificationURL: subscription.LifecycleNotificationURL,
+		ClientState:              subscription.ClientState,
+		ExpirationDateTime:       subscription.ExpirationDateTime,
+	})
+	if err != nil {
+		slog.Error("Failed to persist subscription", "subscription_id", subscriptionID, "exception", err, "operation", "subscriptions")
+	}
+}
+
+// updateTrackedExpiry refreshes the expiration time of a tracked subscription.
+func (g *GraphHelper) updateTrackedExpiry(ctx context.Context, subscriptionID string, expirationDateTime time.Time) {
+	g.mu.Lock()
+	subscription, ok := g.subscriptions[subscriptionID]
+	if ok {
+		subscription.ExpirationDateTime = expirationDateTime
+		g.subscriptions[subscriptionID] = subscription
+	}
+	g.mu.Unlock()
+
+	if !ok || g.Database == nil {
+		return
+	}
+	if err := g.Database.UpdateSubscriptionExpiry(ctx, subscriptionID, expirationDateTime); err != nil {
+		slog.Error("Failed to persist subscription expiry", "subscription_id", subscriptionID, "exception", err, "operation", "subscriptions")
+	}
+}
+
+// untrackSubscription removes the state of a deleted subscription.
+func (g *GraphHelper) untrackSubscription(ctx context.Context, subscriptionID string) {
+	g.mu.Lock()
+	delete(g.subscriptions, subscriptionID)
+	g.mu.Unlock()
+
+	if g.Database == nil {
+		return
+	}
+	if err := g.Database.DeleteSubscription(ctx, subscriptionID); err != nil {
+		slog.Error("Failed to delete persisted subscription", "subscription_id", subscriptionID, "exception", err, "operation", "subscriptions")
+	}
+}
+
+// trackedSubscription returns the state of a subscription, if known.
+func (g *GraphHelper) trackedSubscription(subscriptionID string) (trackedSubscription, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	subscription, ok := g.subscriptions[subscriptionID]
+	return subscription, ok
+}
+
+// loadTrackedSubscriptions restores the subscriptions persisted by a previous run.
+func (g *GraphHelper) loadTrackedSubscriptions(ctx context.Context) error {
+	if g.Database == nil {
+		return nil
+	}
+
+	persisted, err := g.Database.GetSubscriptions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load persisted subscriptions: %w", err)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.subscriptions == nil {
+		g.subscriptions = make(map[string]trackedSubscription, len(persisted))
+	}
+	for _, subscription := range persisted {
+		g.subscriptions[subscription.ID] = trackedSubscription{
+			Resource:                 subscription.Resource,
+			NotificationURL:          subscription.NotificationURL,
+			LifecycleNotificationURL: subscription.LifecycleNotificationURL,
+			ClientState:              subscription.ClientState,
+			ExpirationDateTime:       subscription.ExpirationDateTime,
+		}
+	}
+
+	slog.Debug("Persisted subscriptions loaded", "count", len(persisted), "operation", "subscriptions")
+	return nil
+}
+
+// forgetVanishedSubscriptions untracks subscriptions which no longer exist in Graph.
+func (g *GraphHelper) forgetVanishedSubscriptions(ctx context.Context, existing []gmodels.Subscriptionable) {
+	active := make(map[string]struct{}, len(existing))
+	for _, subscription := range existing {
+		active[safeString(subscription.GetId())] = struct{}{}
+	}
+
+	for subscriptionID := range g.trackedSubscriptions() {
+		if _, ok := active[subscriptionID]; !ok {
+			slog.Info("Tracked subscription no longer exists", "subscription_id", subscriptionID, "operation", "subscriptions")
+			g.untrackSubscription(ctx, subscriptionID)
+		}
+	}
+}
+
+// RecordSubscriptionNotification stores the time a notification of the subscription was received.
+func (g *GraphHelper) RecordSubscriptionNotification(ctx context.Context, subscriptionID string) {
+	if g.Database == nil {
+		return
+	}
+	if err := g.Database.SaveSubscriptionNotification(ctx, subscriptionID, time.Now()); err != nil {
+		slog.Error("Failed to record subscription notification", "subscription_id", subscriptionID, "exception", err, "operation", "subscriptions")
+	}
+}
+
+// SubscriptionResource returns the resource of a subscription managed by this instance.
+func (g *GraphHelper) SubscriptionResource(subscriptionID string) (string, bool) {
+	subscription, ok := g.trackedSubscription(subscriptionID)
+	if !ok || subscription.Resource == "" {
+		return "", false
+	}
+	return subscription.Resource, true
+}
+
+// trackedSubscriptions returns a snapshot of all tracked subscriptions.
+func (g *GraphHelper) trackedSubscriptions() map[string]trackedSubscription {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	snapshot := make(map[string]trackedSubscription, len(g.subscriptions))
+	for id, subscription := range g.subscriptions {
+		snapshot[id] = subscription
+	}
+	return snapshot
+}
+
+// NextSubscriptionExpiry returns the earliest expiration time among tracked subscriptions.
+// It reports false when no subscription is tracked.
+func (g *GraphHelper) NextSubscriptionExpiry() (time.Time, bool) {
+	var next time.Time
+	for _, subscription := range g.trackedSubscriptions() {
+		if subscription.ExpirationDateTime.IsZero() {
+			continue
+		}
+		if next.IsZero() || subscription.ExpirationDateTime.Before(next) {
+			next = subscription.ExpirationDateTime
+		}
+	}
+	return next, !next.IsZero()
+}
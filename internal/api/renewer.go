@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"microsoft-apps-exporter/internal/configuration"
+	"microsoft-apps-exporter/internal/models"
+	"time"
+
+	gmodels "github.com/microsoftgraph/msgraph-sdk-go/models"
+)
+
+// SubscriptionRenewer proactively extends subscriptions before they expire, so that the exporter
+// does not depend on lifecycle notifications to keep them alive.
+type SubscriptionRenewer struct {
+	Graph    *GraphHelper
+	Margin   time.Duration // Subscriptions expiring within the margin are renewed
+	Interval time.Duration // How often the subscriptions are checked
+}
+
+// NewSubscriptionRenewer creates a new SubscriptionRenewer with the configured margin and interval.
+func NewSubscriptionRenewer(graph *GraphHelper) *SubscriptionRenewer {
+	config := configuration.GetConfig()
+	return &SubscriptionRenewer{
+		Graph:    graph,
+		Margin:   config.SUBSCRIPTION_RENEWAL_MARGIN,
+		Interval: config.SUBSCRIPTION_RENEWAL_INTERVAL,
+	}
+}
+
+// Run checks the subscriptions on every interval until the context is cancelled.
+func (sr *SubscriptionRenewer) Run(ctx context.Context) {
+	if sr.Interval <= 0 {
+		slog.Info("Subscription renewal disabled", "operation", "renewal")
+		return
+	}
+
+	slog.Info("Subscription renewer started", "interval", sr.Interval, "margin", sr.Margin, "operation", "renewal")
+
+	ticker := time.NewTicker(sr.Interval)
+	defer ticker.Stop()
+
+	for {
+		sr.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			slog.Info("Subscription renewer stopped", "operation", "renewal")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// NextExpiry returns the earliest expiration time among the managed subscriptions.
+// It reports false when no subscription is managed.
+func (sr *SubscriptionRenewer) NextExpiry() (time.Time, bool) {
+	return sr.Graph.NextSubscriptionExpiry()
+}
+
+// Check renews subscriptions expiring within the margin and recreates the ones that have vanished.
+func (sr *SubscriptionRenewer) Check(ctx context.Context) {
+	subscriptions, err := sr.Graph.GetSubscriptions(ctx)
+	if err != nil {
+		slog.Error("Failed to check subscriptions for renewal", "exception", err, "operation", "renewal")
+		return
+	}
+
+	active := make(map[string]struct{}, len(subscriptions))
+	for _, subscription := range subscriptions {
+		subscriptionID := safeString(subscription.GetId())
+		active[subscriptionID] = struct{}{}
+		if expirationDateTime := subscription.GetExpirationDateTime(); expirationDateTime != nil {
+			sr.Graph.updateTrackedExpiry(ctx, subscriptionID, *expirationDateTime)
+		}
+	}
+
+	now := time.Now()
+	managed := make(map[string]struct{})
+	for subscriptionID, tracked := range sr.Graph.trackedSubscriptions() {
+		if _, exists := active[subscriptionID]; !exists {
+			slog.Warn("Subscription vanished, recreating", "subscription_id", subscriptionID,
+				"resource", tracked.Resource, "operation", "renewal")
+
+			if _, err := sr.Graph.RecreateResourceSubscription(ctx, subscriptionID, tracked.Resource); err != nil {
+				slog.Error("Failed to recreate subscription", "subscription_id", subscriptionID,
+					"exception", err, "operation", "renewal")
+				continue
+			}
+			managed[tracked.Resource] = struct{}{}
+			continue
+		}
+		managed[tracked.Resource] = struct{}{}
+
+		if !dueForRenewal(tracked.ExpirationDateTime, now, sr.Margin) {
+			continue
+		}
+
+		if _, err := sr.Graph.UpdateSubscription(ctx, subscriptionID); err != nil {
+			slog.Error("Failed to renew subscription", "subscription_id", subscriptionID,
+				"expiration", tracked.ExpirationDateTime, "exception", err, "operation", "renewal")
+		}
+	}
+
+	sr.ensureMissing(ctx, subscriptions, managed)
+
+	if next, ok := sr.NextExpiry(); ok {
+		slog.Info("Subscriptions checked", "count", len(managed), "next_expiry", next, "operation", "renewal")
+	}
+}
+
+// ensureMissing creates subscriptions for configured lists that have none, e.g. after a failed recreation.
+func (sr *SubscriptionRenewer) ensureMissing(ctx context.Context, subscriptions []gmodels.Subscriptionable, managed map[string]struct{}) {
+	config := configuration.GetConfig()
+	if config.Sharepoint == nil {
+		return
+	}
+
+	for _, list := range config.Sharepoint.Lists {
+		resource := models.GenerateSharepointResourceString(list.SiteID, list.ListID)
+		if _, ok := managed[resource]; ok {
+			continue
+		}
+
+		if _, err := sr.Graph.ensureResourceSubscriptionFrom(ctx, subscriptions, resource, models.WebhookSharepointEndpoint); err != nil {
+			slog.Error("Failed to ensure missing subscription", "resource", resource, "exception", err, "operation", "renewal")
+		}
+	}
+}
+
+// dueForRenewal reports whether a subscription expiring at expirationDateTime has to be renewed.
+// Subscriptions with an unknown expiration are always renewed.
+func dueForRenewal(expirationDateTime, now time.Time, margin time.Duration) bool {
+	return expirationDateTime.IsZero() || !expirationDateTime.After(now.Add(margin))
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"microsoft-apps-exporter/internal/configuration"
+
+	khttp "github.com/microsoft/kiota-http-go"
+	msgraphgocore "github.com/microsoftgraph/msgraph-sdk-go-core"
+)
+
+const retryAttemptHeader = "Retry-Attempt"
+
+// operationKey is the context key holding the name of the Graph operation being performed.
+type operationKey struct{}
+
+// withOperation annotates the context with the name of the Graph operation, used by the retry logs.
+func withOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// operationFromContext returns the Graph operation name stored in the context, if any.
+func operationFromContext(ctx context.Context) string {
+	operation, _ := ctx.Value(operationKey{}).(string)
+	return operation
+}
+
+// retryHandler is a Graph request adapter middleware retrying throttled (429) and transiently
+// failing (503, 504) requests. It honors Retry-After, otherwise it backs off exponentially with jitter.
+// A request is retried at most MaxRetries times and the total waiting time never exceeds Budget.
+type retryHandler struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	Budget     time.Duration
+
+	sleep func(ctx context.Context, delay time.Duration) error
+}
+
+// newRetryHandler creates a new retryHandler with the configured limits.
+func newRetryHandler() *retryHandler {
+	config := configuration.GetConfig()
+	return &retryHandler{
+		MaxRetries: config.GRAPH_RETRY_MAX_RETRIES,
+		BaseDelay:  config.GRAPH_RETRY_BASE_DELAY,
+		MaxDelay:   config.GRAPH_RETRY_MAX_DELAY,
+		Budget:     config.GRAPH_RETRY_BUDGET,
+		sleep:      sleepContext,
+	}
+}
+
+// Intercept implements khttp.Middleware.
+func (h *retryHandler) Intercept(pipeline khttp.Pipeline, middlewareIndex int, req *http.Request) (*http.Response, error) {
+	response, err := pipeline.Next(req, middlewareIndex)
+
+	var waited time.Duration
+	for attempt := 1; err == nil && isRetriableStatus(response.StatusCode) && attempt <= h.MaxRetries; attempt++ {
+		retry, ok := rewindRequest(req)
+		if !ok {
+			break
+		}
+
+		delay := h.retryDelay(response, attempt)
+		if waited+delay > h.Budget {
+			slog.Warn("Graph request retry budget exhausted", "graph_operation", requestOperation(req),
+				"status", response.StatusCode, "attempt", attempt, "waited", waited, "delay", delay, "operation", "graph")
+			break
+		}
+
+		slog.Warn("Retrying Graph request", "graph_operation", requestOperation(req),
+			"status", response.StatusCode, "attempt", attempt, "delay", delay, "operation", "graph")
+
+		drainBody(response)
+		if err := h.sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+		waited += delay
+
+		retry.Header.Set(retryAttemptHeader, strconv.Itoa(attempt))
+		response, err = pipeline.Next(retry, middlewareIndex)
+	}
+
+	return response, err
+}
+
+// retryDelay returns the delay before the given retry attempt.
+func (h *retryHandler) retryDelay(response *http.Response, attempt int) time.Duration {
+	if delay, ok := retryAfter(response, time.Now()); ok {
+		return delay
+	}
+
+	backoff := h.BaseDelay << (attempt - 1)
+	if backoff <= 0 || backoff > h.MaxDelay { // Overflow or cap
+		backoff = h.MaxDelay
+	}
+	if backoff <= 0 {
+		return 0
+	}
+
+	// Keep half of the backoff and randomize the rest to spread concurrent retries
+	half := backoff / 2
+	return half + rand.N(backoff-half+1)
+}
+
+// retryAfter parses the Retry-After header given either in seconds or as an HTTP date.
+func retryAfter(response *http.Response, now time.Time) (time.Duration, bool) {
+	value := response.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// isRetriableStatus reports whether the status code signals throttling or a transient failure.
+func isRetriableStatus(status int) bool {
+	return status == http.StatusTooManyRequests ||
+		status == http.StatusServiceUnavailable ||
+		status == http.StatusGatewayTimeout
+}
+
+// rewindRequest returns a copy of the request that can be sent again, or false if its body cannot be replayed.
+func rewindRequest(req *http.Request) (*http.Request, bool) {
+	retry := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return retry, true
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, false
+		}
+		retry.Body = body
+		return retry, true
+	}
+
+	// The Kiota request adapter sends the content as a seekable body
+	if seeker, ok := req.Body.(io.Seeker); ok {
+		if _, err := seeker.Seek(0, io.SeekStart); err == nil {
+			return retry, true
+		}
+	}
+	return nil, false
+}
+
+// requestOperation returns the operation name of the request, falling back to its method and path.
+func requestOperation(req *http.Request) string {
+	if operation := operationFromContext(req.Context()); operation != "" {
+		return operation
+	}
+	return req.Method + " " + req.URL.Path
+}
+
+// drainBody discards and closes the body of a response which is going to be retried.
+func drainBody(response *http.Response) {
+	if response.Body == nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, response.Body)
+	response.Body.Close()
+}
+
+// sleepContext waits for the delay or until the context is cancelled.
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// graphMiddlewares returns the default Graph middlewares with the built-in retry handler replaced by retryHandler,
+// followed by metricsHandler.
+func graphMiddlewares(options *msgraphgocore.GraphClientOptions) []khttp.Middleware {
+	middlewares := msgraphgocore.GetDefaultMiddlewaresWithOptions(options)
+
+	result := []khttp.Middleware{newRetryHandler(), metricsHandler{}}
+	for _, middleware := range middlewares {
+		if _, isRetry := middleware.(*khttp.RetryHandler); isRetry {
+			continue
+		}
+		result = append(result, middleware)
+	}
+	return result
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"microsoft-apps-exporter/internal/models"
 	"strings"
@@ -9,8 +10,8 @@ import (
 )
 
 // GetListMetadata retrieves metadata of a SharePoint list by site ID and list ID.
-func (g *GraphHelper) GetList(siteID, listID string) ([]models.ListMetadata, error) {
-	listResponse, err := g.requestList(siteID, listID)
+func (g *GraphHelper) GetList(ctx context.Context, siteID, listID string) ([]models.ListMetadata, error) {
+	listResponse, err := g.requestList(ctx, siteID, listID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch list metadata: %w", err)
 	}
@@ -26,32 +27,47 @@ func (g *GraphHelper) GetList(siteID, listID string) ([]models.ListMetadata, err
 	}, nil
 }
 
-// GetListItemsWithDelta retrieves SharePoint list items using Delta Query for tracking changes.
-func (g *GraphHelper) GetListItemsWithDelta(
-	siteID, listID string, deltaLink *string, options *graphsites.ItemListsItemItemsDeltaRequestBuilderGetRequestConfiguration,
-) (*string, *[]models.ListItem, error) {
-	newDeltaLink, itemsResponse, err := g.requestListItemsWithDelta(siteID, listID, deltaLink, options)
+// GetListColumns retrieves the column definitions of a SharePoint list, hidden columns included.
+func (g *GraphHelper) GetListColumns(ctx context.Context, siteID, listID string) ([]models.ColumnDefinition, error) {
+	columnsResponse, err := g.requestListColumns(ctx, siteID, listID)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to retrieve list items: %w", err)
+		return nil, fmt.Errorf("failed to fetch list columns: %w", err)
 	}
 
-	listItems := make([]models.ListItem, 0, len(itemsResponse))
-	for _, itemResponse := range itemsResponse {
-		listItem, err := g.parseListItemResponse(itemResponse)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to parse list item fields: %w", err)
-		}
+	columns := make([]models.ColumnDefinition, 0, len(columnsResponse))
+	for _, columnResponse := range columnsResponse {
+		columns = append(columns, parseColumnDefinition(columnResponse))
+	}
+	return columns, nil
+}
 
-		listItem.Metadata = models.ListItemMetadata{
-			ID:     *itemResponse.GetId(),
-			ListID: listID,
-			SiteID: siteID,
-			ETag:   safeString(itemResponse.GetETag()),
+// ListItemsPage is a page of list items returned by a delta query.
+type ListItemsPage struct {
+	Items     []models.ListItem
+	NextLink  *string // Link of the following page, nil on the last page
+	DeltaLink *string // Link of the next delta query, set on the last page
+}
+
+// ForEachListItemsPage retrieves SharePoint list items page by page using Delta Query, starting from the link,
+// which is either a delta link, the next link of an interrupted query or nil to enumerate the whole list.
+// Every page is handed to fn before the following one is requested, an error returned by fn stops the query.
+// It returns the number of Graph pages requested.
+func (g *GraphHelper) ForEachListItemsPage(
+	ctx context.Context, siteID, listID string, link *string, options *graphsites.ItemListsItemItemsDeltaRequestBuilderGetRequestConfiguration,
+	fn func(page ListItemsPage) error,
+) (int, error) {
+	return g.forEachDeltaPage(ctx, siteID, listID, link, options, func(response graphsites.ItemListsItemItemsDeltaGetResponseable) error {
+		listItems, err := g.parseListItems(siteID, listID, response.GetValue())
+		if err != nil {
+			return err
 		}
-		listItems = append(listItems, *listItem)
-	}
 
-	return newDeltaLink, &listItems, nil
+		return fn(ListItemsPage{
+			Items:     listItems,
+			NextLink:  response.GetOdataNextLink(),
+			DeltaLink: response.GetOdataDeltaLink(),
+		})
+	})
 }
 
 // NewListItemsWithDeltaOptions generates request configuration for delta-tracked list item retrieval.
@@ -1,68 +1,113 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"microsoft-apps-exporter/internal/models"
+	"time"
 
 	gmodels "github.com/microsoftgraph/msgraph-sdk-go/models"
 	graphsites "github.com/microsoftgraph/msgraph-sdk-go/sites"
 )
 
 // requestList retrieves a SharePoint list using its site and list IDs.
-func (g *GraphHelper) requestList(siteID, listID string) (gmodels.Listable, error) {
-	return g.Client.Sites().BySiteId(siteID).Lists().ByListId(listID).Get(g.Ctx, nil)
+func (g *GraphHelper) requestList(ctx context.Context, siteID, listID string) (gmodels.Listable, error) {
+	ctx, cancel := g.requestContext(ctx, "requestList")
+	defer cancel()
+
+	return g.Client.Sites().BySiteId(siteID).Lists().ByListId(listID).Get(ctx, nil)
 }
 
-// requestListItemsWithDelta retrieves paginated list items, updating delta links as needed.
-func (g *GraphHelper) requestListItemsWithDelta(
-	siteID, listID string, deltaLink *string,
-	options *graphsites.ItemListsItemItemsDeltaRequestBuilderGetRequestConfiguration,
-) (*string, []gmodels.ListItemable, error) {
-	var (
-		collectionResponse graphsites.ItemListsItemItemsDeltaGetResponseable
-		err                error
-	)
+// requestListColumns retrieves every page of the column definitions of a SharePoint list.
+func (g *GraphHelper) requestListColumns(ctx context.Context, siteID, listID string) ([]gmodels.ColumnDefinitionable, error) {
+	ctx, cancel := g.requestContext(ctx, "requestListColumns")
+	defer cancel()
 
-	// Use delta link if available
-	req := g.Client.Sites().BySiteId(siteID).Lists().ByListId(listID).Items()
-	if deltaLink != nil {
-		collectionResponse, err = req.WithUrl(*deltaLink).Delta().GetAsDeltaGetResponse(g.Ctx, options)
-	} else {
-		collectionResponse, err = req.Delta().GetAsDeltaGetResponse(g.Ctx, options)
-	}
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to fetch list items: %w", err)
-	}
+	req := g.Client.Sites().BySiteId(siteID).Lists().ByListId(listID).Columns()
+	var columns []gmodels.ColumnDefinitionable
+	for {
+		response, err := req.Get(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, response.GetValue()...)
 
-	listItems := collectionResponse.GetValue()
+		nextLink := response.GetOdataNextLink()
+		if nextLink == nil {
+			return columns, nil
+		}
+		req = req.WithUrl(*nextLink)
+	}
+}
 
-	// Extract optional Top parameter safely
-	var topLimit int
-	if options != nil && options.QueryParameters.Top != nil {
-		topLimit = int(*options.QueryParameters.Top)
+// forEachDeltaPage requests the pages of a delta query one at a time, starting from the link if any,
+// and hands each of them to fn. It stops after the page carrying the delta link, after the last page
+// or once fn returns an error, which is returned as is. It returns the number of requested pages.
+func (g *GraphHelper) forEachDeltaPage(
+	ctx context.Context, siteID, listID string, link *string,
+	options *graphsites.ItemListsItemItemsDeltaRequestBuilderGetRequestConfiguration,
+	fn func(response graphsites.ItemListsItemItemsDeltaGetResponseable) error,
+) (int, error) {
+	// Use the link if available
+	req := g.Client.Sites().BySiteId(siteID).Lists().ByListId(listID).Items()
+	deltaReq := req.Delta()
+	if link != nil {
+		deltaReq = req.WithUrl(*link).Delta()
 	}
 
-	// Handle pagination and delta link updates
+	pages := 0
 	for {
-		if delta := collectionResponse.GetOdataDeltaLink(); delta != nil {
-			return delta, listItems, nil
+		response, err := g.requestDeltaPage(ctx, deltaReq, options)
+		if err != nil {
+			if pages == 0 {
+				return pages, fmt.Errorf("failed to fetch list items: %w", err)
+			}
+			return pages, fmt.Errorf("error fetching next page: %w", err)
+		}
+		pages++
+
+		if err := fn(response); err != nil {
+			return pages, err
 		}
 
-		nextLink := collectionResponse.GetOdataNextLink()
-		if nextLink == nil || (topLimit > 0 && len(listItems) >= topLimit) {
-			break
+		nextLink := response.GetOdataNextLink()
+		if response.GetOdataDeltaLink() != nil || nextLink == nil {
+			return pages, nil
 		}
+		deltaReq = req.WithUrl(*nextLink).Delta()
+	}
+}
+
+// requestDeltaPage requests a single page of a delta query.
+func (g *GraphHelper) requestDeltaPage(
+	ctx context.Context, req *graphsites.ItemListsItemItemsDeltaRequestBuilder,
+	options *graphsites.ItemListsItemItemsDeltaRequestBuilderGetRequestConfiguration,
+) (graphsites.ItemListsItemItemsDeltaGetResponseable, error) {
+	ctx, cancel := g.requestContext(ctx, "requestListItemsWithDelta")
+	defer cancel()
 
-		collectionResponse, err = req.WithUrl(*nextLink).Delta().GetAsDeltaGetResponse(g.Ctx, options)
+	return req.GetAsDeltaGetResponse(ctx, options)
+}
+
+// parseListItems converts the items of a delta page, deleted items keep an empty etag.
+func (g *GraphHelper) parseListItems(siteID, listID string, itemsResponse []gmodels.ListItemable) ([]models.ListItem, error) {
+	listItems := make([]models.ListItem, 0, len(itemsResponse))
+	for _, itemResponse := range itemsResponse {
+		listItem, err := g.parseListItemResponse(itemResponse)
 		if err != nil {
-			return nil, nil, fmt.Errorf("error fetching next page: %w", err)
+			return nil, fmt.Errorf("failed to parse list item fields: %w", err)
 		}
 
-		listItems = append(listItems, collectionResponse.GetValue()...)
+		listItem.Metadata = models.ListItemMetadata{
+			ID:     *itemResponse.GetId(),
+			ListID: listID,
+			SiteID: siteID,
+			ETag:   safeString(itemResponse.GetETag()),
+		}
+		listItems = append(listItems, *listItem)
 	}
-
-	return nil, listItems, nil
+	return listItems, nil
 }
 
 // parseListItemResponse extracts and deserializes list item fields into a structured format.
@@ -110,9 +155,85 @@ func deserializeFields(serializedFields []byte) (models.ListItemMappedFields, er
 	return mappedData, nil
 }
 
+// parseColumnDefinition converts a column definition, the type is told by the facet set on the column.
+func parseColumnDefinition(columnResponse gmodels.ColumnDefinitionable) models.ColumnDefinition {
+	column := models.ColumnDefinition{
+		Name:        safeString(columnResponse.GetName()),
+		DisplayName: safeString(columnResponse.GetDisplayName()),
+		Type:        models.ColumnTypeText,
+		Required:    safeBool(columnResponse.GetRequired()),
+	}
+
+	switch {
+	case columnResponse.GetText() != nil:
+		text := columnResponse.GetText()
+		if safeBool(text.GetAllowMultipleLines()) {
+			column.Type = models.ColumnTypeNote
+		} else if maxLength := text.GetMaxLength(); maxLength != nil {
+			column.MaxLength = int(*maxLength)
+		}
+	case columnResponse.GetNumber() != nil:
+		column.Type = models.ColumnTypeNumber
+	case columnResponse.GetCurrency() != nil:
+		column.Type = models.ColumnTypeCurrency
+	case columnResponse.GetBoolean() != nil:
+		column.Type = models.ColumnTypeBoolean
+	case columnResponse.GetDateTime() != nil:
+		column.Type = models.ColumnTypeDateTime
+		if safeString(columnResponse.GetDateTime().GetFormat()) == "dateOnly" {
+			column.Type = models.ColumnTypeDate
+		}
+	case columnResponse.GetChoice() != nil:
+		column.Type = models.ColumnTypeChoice
+		column.Multiple = safeString(columnResponse.GetChoice().GetDisplayAs()) == "checkBoxes"
+	case columnResponse.GetLookup() != nil:
+		column.Type = models.ColumnTypeLookup
+		column.Multiple = safeBool(columnResponse.GetLookup().GetAllowMultipleValues())
+	case columnResponse.GetPersonOrGroup() != nil:
+		column.Type = models.ColumnTypePersonOrGroup
+		column.Multiple = safeBool(columnResponse.GetPersonOrGroup().GetAllowMultipleSelection())
+	case columnResponse.GetHyperlinkOrPicture() != nil:
+		column.Type = models.ColumnTypeHyperlinkOrPicture
+	case columnResponse.GetGeolocation() != nil:
+		column.Type = models.ColumnTypeGeolocation
+	case columnResponse.GetTerm() != nil:
+		column.Type = models.ColumnTypeTerm
+		column.Multiple = safeBool(columnResponse.GetTerm().GetAllowMultipleValues())
+	case columnResponse.GetThumbnail() != nil:
+		column.Type = models.ColumnTypeThumbnail
+	case columnResponse.GetCalculated() != nil:
+		switch safeString(columnResponse.GetCalculated().GetOutputType()) {
+		case "number":
+			column.Type = models.ColumnTypeNumber
+		case "currency":
+			column.Type = models.ColumnTypeCurrency
+		case "boolean":
+			column.Type = models.ColumnTypeBoolean
+		case "dateTime":
+			column.Type = models.ColumnTypeDateTime
+			if safeString(columnResponse.GetCalculated().GetFormat()) == "dateOnly" {
+				column.Type = models.ColumnTypeDate
+			}
+		}
+	}
+
+	return column
+}
+
 func safeString(value *string) string {
 	if value == nil {
 		return ""
 	}
 	return *value
 }
+
+func safeBool(value *bool) bool {
+	return value != nil && *value
+}
+
+func safeTime(value *time.Time) time.Time {
+	if value == nil {
+		return time.Time{}
+	}
+	return *value
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"microsoft-apps-exporter/internal/configuration"
@@ -10,20 +11,27 @@ import (
 	gmodels "github.com/microsoftgraph/msgraph-sdk-go/models"
 )
 
-const (
-	webhookSubscriptionEndpoint = "/webhook/subscription-notification"
-	defaultSubscriptionExpiry   = 48 * time.Hour
-	subscriptionUpdateExpiry    = 72 * time.Hour
-)
+const webhookSubscriptionEndpoint = "/webhook/subscription-notification"
 
 // EnsureResourcesSubscriptions ensures that subscriptions exist for all configured resources.
+// Persisted subscriptions are reconciled against the ones existing in Graph, which are requested once.
 // It returns a slice of active subscriptions or an error if the process fails.
-func (g *GraphHelper) EnsureResourcesSubscriptions() ([]gmodels.Subscriptionable, error) {
+func (g *GraphHelper) EnsureResourcesSubscriptions(ctx context.Context) ([]gmodels.Subscriptionable, error) {
 	var subscriptions []gmodels.Subscriptionable
 	activeResources := make(map[string]struct{})
 
 	slog.Info("Ensuring MS Graph API resources subscriptions are active", "operation", "subscriptions")
 
+	if err := g.loadTrackedSubscriptions(ctx); err != nil {
+		return nil, err
+	}
+
+	existing, err := g.GetSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+	g.forgetVanishedSubscriptions(ctx, existing)
+
 	config := configuration.GetConfig()
 	if config.Sharepoint != nil {
 		// Ensure subscriptions exist for all configured SharePoint resources
@@ -31,7 +39,7 @@ func (g *GraphHelper) EnsureResourcesSubscriptions() ([]gmodels.Subscriptionable
 			resource := models.GenerateSharepointResourceString(list.SiteID, list.ListID)
 			activeResources[resource] = struct{}{} // Mark as active
 
-			subscription, err := g.ensureResourceSubscription(resource, models.WebhookSharepointEndpoint)
+			subscription, err := g.ensureResourceSubscriptionFrom(ctx, existing, resource, models.WebhookSharepointEndpoint)
 			if err != nil {
 				return nil, fmt.Errorf("failed to ensure subscription for resource %s: %w", resource, err)
 			}
@@ -39,8 +47,7 @@ func (g *GraphHelper) EnsureResourcesSubscriptions() ([]gmodels.Subscriptionable
 		}
 	}
 
-	err := g.deleteInactiveSubscriptions(activeResources)
-	if err != nil {
+	if err := g.deleteInactiveSubscriptions(ctx, existing, activeResources); err != nil {
 		return nil, err
 	}
 
@@ -49,7 +56,7 @@ func (g *GraphHelper) EnsureResourcesSubscriptions() ([]gmodels.Subscriptionable
 }
 
 // CreateSharepointSubscription creates a new subscription for the specified SharePoint resource.
-func (g *GraphHelper) CreateResourceSubscription(resource, webhookResourceEndpoint string) (gmodels.Subscriptionable, error) {
+func (g *GraphHelper) CreateResourceSubscription(ctx context.Context, resource, webhookResourceEndpoint string) (gmodels.Subscriptionable, error) {
 	config := configuration.GetConfig()
 	webhookBaseURL := config.WEBHOOK_EXTERNAL_BASE_URL
 
@@ -57,17 +64,26 @@ func (g *GraphHelper) CreateResourceSubscription(resource, webhookResourceEndpoi
 	changeType := "updated"
 	notificationUrl := webhookBaseURL + webhookResourceEndpoint
 	lifecycleNotificationUrl := webhookBaseURL + webhookSubscriptionEndpoint
-	expirationDateTime := time.Now().Add(defaultSubscriptionExpiry)
+	expirationDateTime := time.Now().Add(config.SUBSCRIPTION_EXPIRY)
 	latestSupportedTlsVersion := "v1_2"
 
+	clientState, err := newClientState()
+	if err != nil {
+		return nil, err
+	}
+
 	requestBody.SetChangeType(&changeType)
 	requestBody.SetNotificationUrl(&notificationUrl)
 	requestBody.SetLifecycleNotificationUrl(&lifecycleNotificationUrl)
 	requestBody.SetResource(&resource)
 	requestBody.SetExpirationDateTime(&expirationDateTime)
 	requestBody.SetLatestSupportedTlsVersion(&latestSupportedTlsVersion)
+	requestBody.SetClientState(&clientState)
+
+	requestCtx, cancel := g.requestContext(ctx, "CreateResourceSubscription")
+	defer cancel()
 
-	subscription, err := g.Client.Subscriptions().Post(g.Ctx, requestBody, nil)
+	subscription, err := g.Client.Subscriptions().Post(requestCtx, requestBody, nil)
 	if err != nil {
 		slog.Debug("Failed to create subscription",
 			slog.Group("requestBody",
@@ -81,6 +97,13 @@ func (g *GraphHelper) CreateResourceSubscription(resource, webhookResourceEndpoi
 
 		return nil, fmt.Errorf("failed to create subscription: %w", err)
 	}
+	g.trackSubscription(ctx, *subscription.GetId(), trackedSubscription{
+		Resource:                 resource,
+		NotificationURL:          notificationUrl,
+		LifecycleNotificationURL: lifecycleNotificationUrl,
+		ClientState:              clientState,
+		ExpirationDateTime:       safeTime(subscription.GetExpirationDateTime()),
+	})
 
 	slog.Info("Subscription created successfully", "subscription_id", *subscription.GetId(),
 		"resource", resource, "operation", "subscriptions")
@@ -88,8 +111,11 @@ func (g *GraphHelper) CreateResourceSubscription(resource, webhookResourceEndpoi
 }
 
 // GetSubscriptions retrieves all active subscriptions.
-func (g *GraphHelper) GetSubscriptions() ([]gmodels.Subscriptionable, error) {
-	subscriptions, err := g.Client.Subscriptions().Get(g.Ctx, nil)
+func (g *GraphHelper) GetSubscriptions(ctx context.Context) ([]gmodels.Subscriptionable, error) {
+	ctx, cancel := g.requestContext(ctx, "GetSubscriptions")
+	defer cancel()
+
+	subscriptions, err := g.Client.Subscriptions().Get(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to request subscriptions: %w", err)
 	}
@@ -99,38 +125,62 @@ func (g *GraphHelper) GetSubscriptions() ([]gmodels.Subscriptionable, error) {
 }
 
 // UpdateSubscription updates the expiration time of a specific subscription.
-func (g *GraphHelper) UpdateSubscription(subscriptionID string) (gmodels.Subscriptionable, error) {
+func (g *GraphHelper) UpdateSubscription(ctx context.Context, subscriptionID string) (gmodels.Subscriptionable, error) {
+	config := configuration.GetConfig()
 	requestBody := gmodels.NewSubscription()
-	expirationDateTime := time.Now().Add(subscriptionUpdateExpiry)
+	expirationDateTime := time.Now().Add(config.SUBSCRIPTION_UPDATE_EXPIRY)
 	requestBody.SetExpirationDateTime(&expirationDateTime)
 
-	subscription, err := g.Client.Subscriptions().BySubscriptionId(subscriptionID).Patch(g.Ctx, requestBody, nil)
+	requestCtx, cancel := g.requestContext(ctx, "UpdateSubscription")
+	defer cancel()
+
+	subscription, err := g.Client.Subscriptions().BySubscriptionId(subscriptionID).Patch(requestCtx, requestBody, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update subscription %s: %w", subscriptionID, err)
 	}
+	g.updateTrackedExpiry(ctx, subscriptionID, safeTime(subscription.GetExpirationDateTime()))
 
 	slog.Info("Subscription updated successfully", "subscription_id", subscriptionID, "operation", "subscriptions")
 	return subscription, nil
 }
 
 // DeleteSubscription deletes a specific subscription by its ID.
-func (g *GraphHelper) DeleteSubscription(subscriptionID string) error {
-	err := g.Client.Subscriptions().BySubscriptionId(subscriptionID).Delete(g.Ctx, nil)
+func (g *GraphHelper) DeleteSubscription(ctx context.Context, subscriptionID string) error {
+	requestCtx, cancel := g.requestContext(ctx, "DeleteSubscription")
+	defer cancel()
+
+	err := g.Client.Subscriptions().BySubscriptionId(subscriptionID).Delete(requestCtx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to delete subscription %s: %w", subscriptionID, err)
 	}
+	g.untrackSubscription(ctx, subscriptionID)
 
 	slog.Info("Subscription deleted successfully", "subscription_id", subscriptionID, "operation", "subscriptions")
 	return nil
 }
 
-/* // ReauthorizeSubscription reauthorizes a specific subscription by its ID.
-func (g *GraphHelper) ReauthorizeSubscription(subscriptionID string) error {
-	err := g.Client.Subscriptions().BySubscriptionId(subscriptionID).Reauthorize().Post(g.Ctx, nil)
+// ReauthorizeSubscription reauthorizes a specific subscription by its ID.
+func (g *GraphHelper) ReauthorizeSubscription(ctx context.Context, subscriptionID string) error {
+	ctx, cancel := g.requestContext(ctx, "ReauthorizeSubscription")
+	defer cancel()
+
+	err := g.Client.Subscriptions().BySubscriptionId(subscriptionID).Reauthorize().Post(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to reauthorize subscription %s: %w", subscriptionID, err)
 	}
 
 	slog.Info("Subscription reauthorized successfully", "subscription_id", subscriptionID, "operation", "subscriptions")
 	return nil
-} */
+}
+
+// RecreateResourceSubscription ensures a subscription exists for the SharePoint resource after the
+// previous one has been removed, returning the active subscription.
+func (g *GraphHelper) RecreateResourceSubscription(ctx context.Context, subscriptionID, resource string) (gmodels.Subscriptionable, error) {
+	g.untrackSubscription(ctx, subscriptionID)
+
+	subscription, err := g.ensureResourceSubscription(ctx, resource, models.WebhookSharepointEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recreate subscription for resource %s: %w", resource, err)
+	}
+	return subscription, nil
+}
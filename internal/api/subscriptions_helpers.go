@@ -1,29 +1,36 @@
 package api
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"microsoft-apps-exporter/internal/configuration"
+	"strings"
 
 	gmodels "github.com/microsoftgraph/msgraph-sdk-go/models"
 )
 
 // EnsureSubscription checks if a subscription for the specified SharePoint resource exists.
-// If an existing subscription is found and has the correct webhook URL, it is returned.
-// Otherwise, it creates a new subscription after deleting outdated ones.
-func (g *GraphHelper) ensureResourceSubscription(resource, webhookResourceEndpoint string) (gmodels.Subscriptionable, error) {
-	subscriptions, err := g.GetSubscriptions()
+// If an existing subscription is found and has the correct webhook URL and a known clientState, it is returned.
+// Otherwise, it creates a new subscription after deleting outdated ones. Subscriptions of other deployments are ignored.
+func (g *GraphHelper) ensureResourceSubscription(ctx context.Context, resource, webhookResourceEndpoint string) (gmodels.Subscriptionable, error) {
+	subscriptions, err := g.GetSubscriptions(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get subscriptions: %w", err)
 	}
+	return g.ensureResourceSubscriptionFrom(ctx, subscriptions, resource, webhookResourceEndpoint)
+}
 
+// ensureResourceSubscriptionFrom is ensureResourceSubscription against already requested subscriptions.
+func (g *GraphHelper) ensureResourceSubscriptionFrom(ctx context.Context, subscriptions []gmodels.Subscriptionable, resource, webhookResourceEndpoint string) (gmodels.Subscriptionable, error) {
 	for _, sub := range subscriptions {
-		if *sub.GetResource() == resource {
+		if *sub.GetResource() == resource && g.isSubscriptionOwned(sub) {
 
-			if g.isSubscriptionWebhookURLsMatch(sub, webhookResourceEndpoint) {
+			if g.isSubscriptionWebhookURLsMatch(sub, webhookResourceEndpoint) && g.adoptClientState(ctx, sub) {
 				return sub, nil
 			} else {
-				if err := g.DeleteSubscription(*sub.GetId()); err != nil {
-					return nil, fmt.Errorf("failed to delete subscription with wrong mismatched webhook URL: %w", err)
+				if err := g.DeleteSubscription(ctx, *sub.GetId()); err != nil {
+					return nil, fmt.Errorf("failed to delete subscription with mismatched webhook URL or client state: %w", err)
 				}
 			}
 
@@ -31,28 +38,61 @@ func (g *GraphHelper) ensureResourceSubscription(resource, webhookResourceEndpoi
 		}
 	}
 
-	return g.CreateResourceSubscription(resource, webhookResourceEndpoint)
+	return g.CreateResourceSubscription(ctx, resource, webhookResourceEndpoint)
 }
 
-// deleteInactiveSubscriptions removes subscriptions for resources that are no longer active.
-func (g *GraphHelper) deleteInactiveSubscriptions(activeResources map[string]struct{}) error {
-	existingSubscriptions, err := g.GetSubscriptions()
-	if err != nil {
-		return fmt.Errorf("failed to get existing subscriptions: %w", err)
-	}
+// deleteInactiveSubscriptions removes owned subscriptions for resources that are no longer active.
+// Subscriptions of other deployments sharing the app registration are left untouched.
+func (g *GraphHelper) deleteInactiveSubscriptions(ctx context.Context, existingSubscriptions []gmodels.Subscriptionable, activeResources map[string]struct{}) error {
+	dryRun := configuration.GetConfig().SUBSCRIPTION_CLEANUP_DRY_RUN
 
 	for _, sub := range existingSubscriptions {
 		resource := *sub.GetResource()
 
-		if _, exists := activeResources[resource]; !exists { // If not marked active, delete
-			if err := g.DeleteSubscription(*sub.GetId()); err != nil {
-				return fmt.Errorf("failed to delete inactive subscription for resource %s: %w", resource, err)
-			}
+		if _, exists := activeResources[resource]; exists {
+			continue
+		}
+
+		if !g.isSubscriptionOwned(sub) {
+			slog.Debug("Skipping subscription owned by another deployment", "subscription_id", *sub.GetId(),
+				"resource", resource, "notification_url", safeString(sub.GetNotificationUrl()), "operation", "subscriptions")
+			continue
+		}
+
+		if dryRun {
+			slog.Info("Inactive subscription would be deleted (dry run)", "subscription_id", *sub.GetId(),
+				"resource", resource, "notification_url", safeString(sub.GetNotificationUrl()), "operation", "subscriptions")
+			continue
+		}
+
+		if err := g.DeleteSubscription(ctx, *sub.GetId()); err != nil {
+			return fmt.Errorf("failed to delete inactive subscription for resource %s: %w", resource, err)
 		}
 	}
 	return nil
 }
 
+// isSubscriptionOwned reports whether the subscription belongs to this deployment, i.e. it is tracked
+// by this instance or delivers notifications under the configured webhook base URL.
+func (g *GraphHelper) isSubscriptionOwned(subscription gmodels.Subscriptionable) bool {
+	if _, tracked := g.trackedSubscription(safeString(subscription.GetId())); tracked {
+		return true
+	}
+
+	webhookBaseURL := strings.TrimSuffix(configuration.GetConfig().WEBHOOK_EXTERNAL_BASE_URL, "/")
+	if webhookBaseURL == "" {
+		return false
+	}
+
+	return hasURLBase(safeString(subscription.GetNotificationUrl()), webhookBaseURL) ||
+		hasURLBase(safeString(subscription.GetLifecycleNotificationUrl()), webhookBaseURL)
+}
+
+// hasURLBase reports whether url equals base or is located under it.
+func hasURLBase(url, base string) bool {
+	return url == base || strings.HasPrefix(url, base+"/")
+}
+
 // isSubscriptionWebhookURLsMatch checks if the given subscription has outdated webhook URLs.
 func (g *GraphHelper) isSubscriptionWebhookURLsMatch(subscription gmodels.Subscriptionable, webhookResourceEndpoint string) bool {
 	config := configuration.GetConfig()
@@ -1,10 +1,31 @@
 package webhook
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
 	"net/url"
+
+	"microsoft-apps-exporter/internal/metrics"
+	"microsoft-apps-exporter/internal/sync"
+	"microsoft-apps-exporter/internal/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Endpoint label values of the webhook metrics.
+const (
+	metricsEndpointSharepoint   = "sharepoint"
+	metricsEndpointSubscription = "subscription"
 )
 
+var errUnverifiedNotifications = errors.New("no notification passed client state verification")
+
 // handleValidationToken extracts and responds with the validation token if present in the request URL.
 // This is used for webhook subscription validation.
 func handleValidationToken(w http.ResponseWriter, requestURL *url.URL) (bool, error) {
@@ -19,3 +40,30 @@ func handleValidationToken(w http.ResponseWriter, requestURL *url.URL) (bool, er
 
 	return true, err
 }
+
+// verifyClientState checks the clientState of a notification against its subscription secret.
+// Mismatches are logged as security events.
+func verifyClientState(syncer *sync.Syncer, r *http.Request, subscriptionID, clientState, resource string) bool {
+	if syncer.Graph.VerifyClientState(subscriptionID, clientState) {
+		return true
+	}
+
+	slog.Warn("Notification rejected due to client state mismatch",
+		"subscription_id", subscriptionID, "resource", resource, "remote_addr", r.RemoteAddr,
+		"user_agent", r.UserAgent(), "security_event", "client_state_mismatch", "operation", "security")
+	return false
+}
+
+// startNotificationSpan starts the server span of a notification request, continuing the propagated trace if any.
+func startNotificationSpan(r *http.Request, endpoint string) (context.Context, trace.Span) {
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	return tracing.Tracer().Start(ctx, "webhook."+endpoint, trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.String("http.request.method", r.Method), attribute.String("url.path", r.URL.Path)))
+}
+
+// rejectNotification counts a notification request rejected by the given endpoint and marks its span as failed.
+func rejectNotification(span trace.Span, endpoint, reason string) {
+	metrics.WebhookNotificationsRejected.WithLabelValues(endpoint, reason).Inc()
+	span.SetAttributes(attribute.String("rejected_reason", reason))
+	span.SetStatus(codes.Error, "notification rejected: "+reason)
+}
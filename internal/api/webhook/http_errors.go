@@ -19,6 +19,13 @@ func handleBadRequest(w http.ResponseWriter, message string) {
 	slog.Error("BadRequest respond with message", "message", message, "operation", "webhook")
 }
 
+// handleForbidden responds with a 403 Forbidden status code and a custom message.
+func handleForbidden(w http.ResponseWriter, message string) {
+	w.WriteHeader(http.StatusForbidden)
+	w.Write([]byte("403 - Forbidden: " + message))
+	slog.Error("Webhook server faced Forbidden", "message", message, "operation", "webhook")
+}
+
 // handleInternalError responds with a 500 Internal Server Error status code and a custom message.
 func handleInternalError(w http.ResponseWriter, message string) {
 	w.WriteHeader(http.StatusInternalServerError)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"microsoft-apps-exporter/internal/configuration"
+	"microsoft-apps-exporter/internal/metrics"
 	"microsoft-apps-exporter/internal/models"
 	"microsoft-apps-exporter/internal/sync"
 	"net/http"
@@ -20,6 +21,8 @@ type WebhookServer struct {
 
 var pingEndpoint string = "/webhook/ping"
 
+var metricsEndpoint string = "/metrics"
+
 // NewWebhookServer initializes and configures a new WebhookServer instance.
 func NewWebhookServer(syncer *sync.Syncer) *WebhookServer {
 	config := configuration.GetConfig()
@@ -33,6 +36,7 @@ func NewWebhookServer(syncer *sync.Syncer) *WebhookServer {
 	mux.HandleFunc(pingEndpoint, func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
+	mux.Handle(metricsEndpoint, metrics.Handler())
 
 	return &WebhookServer{
 		Port: port,
@@ -100,14 +104,10 @@ func (ws *WebhookServer) PingWebhookServer() error {
 	return fmt.Errorf("webhook server healtheck did not respond on ping: %v", err)
 }
 
-// Shutdown gracefully stops the webhook server, allowing ongoing requests to complete.
+// Shutdown gracefully stops the webhook server, allowing ongoing requests to complete until the context is done.
 func (ws *WebhookServer) Shutdown(ctx context.Context) {
-	// Allow up to 5 seconds for graceful shutdown
-	shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
-	if err := ws.Server.Shutdown(shutdownCtx); err != nil {
-		slog.Error("Failed to shut down webhook server", "exception", err, "operation", "database")
+	if err := ws.Server.Shutdown(ctx); err != nil {
+		slog.Error("Failed to shut down webhook server", "exception", err, "operation", "webhook")
 	} else {
 		slog.Info("Webhook server is shut down successfully", "operation", "webhook")
 	}
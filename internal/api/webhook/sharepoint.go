@@ -1,6 +1,7 @@
 package webhook
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 
@@ -9,18 +10,35 @@ import (
 	"strings"
 
 	"microsoft-apps-exporter/internal/configuration"
+	"microsoft-apps-exporter/internal/metrics"
 	"microsoft-apps-exporter/internal/models"
 	"microsoft-apps-exporter/internal/sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Request body
 type ResourceUpdateBody struct {
-	Value []struct {
-		Resource     string `json:"resource"`
-		ResourceData struct {
-			OdataType string `json:"@odata.type"`
-		} `json:"resourceData"`
-	} `json:"value"`
+	Value []ResourceUpdateNotification `json:"value"`
+}
+
+// ResourceUpdateNotification is a single change notification of the batched request body.
+type ResourceUpdateNotification struct {
+	SubscriptionID string       `json:"subscriptionId"`
+	ClientState    string       `json:"clientState"`
+	Resource       string       `json:"resource"`
+	ResourceData   ResourceData `json:"resourceData"`
+}
+
+type ResourceData struct {
+	OdataType string `json:"@odata.type"`
+}
+
+// ListResource identifies the SharePoint list a notification refers to.
+type ListResource struct {
+	SiteID string
+	ListID string
 }
 
 // newSharepointHandler returns an HTTP handler for processing SharePoint webhook notifications.
@@ -29,8 +47,12 @@ func newSharepointHandler(syncer *sync.Syncer) http.HandlerFunc {
 		slog.Info("Received SharePoint update notification", "operation", "webhook")
 		defer r.Body.Close()
 
+		ctx, span := startNotificationSpan(r, metricsEndpointSharepoint)
+		defer span.End()
+
 		if r.Method != http.MethodPost {
-			handleMethodNotAllowed(w, fmt.Sprintf("Only POST method allowed, got: "+r.Method))
+			rejectNotification(span, metricsEndpointSharepoint, metrics.ReasonMethodNotAllowed)
+			handleMethodNotAllowed(w, "Only POST method allowed, got: "+r.Method)
 			return
 		}
 
@@ -41,27 +63,48 @@ func newSharepointHandler(syncer *sync.Syncer) http.HandlerFunc {
 		} else if validated { // subscription creation doesnt include resource update
 			return
 		}
+		metrics.WebhookNotifications.WithLabelValues(metricsEndpointSharepoint).Inc()
 
-		siteID, listID, err := extractResourceUpdateData(r)
-		if err != nil {
+		resources, err := extractResourceUpdateData(r, func(n ResourceUpdateNotification) bool {
+			if !verifyClientState(syncer, r, n.SubscriptionID, n.ClientState, n.Resource) {
+				return false
+			}
+			syncer.Graph.RecordSubscriptionNotification(ctx, n.SubscriptionID)
+			return true
+		})
+		if errors.Is(err, errUnverifiedNotifications) {
+			rejectNotification(span, metricsEndpointSharepoint, metrics.ReasonClientState)
+			handleForbidden(w, err.Error())
+			return
+		} else if err != nil {
+			rejectNotification(span, metricsEndpointSharepoint, metrics.ReasonInvalidPayload)
 			handleBadRequest(w, err.Error())
 			return
 		}
 
-		list, found := exctractListReference(siteID, listID)
+		span.SetAttributes(attribute.Int("resources", len(resources)))
 
-		// Validate if the struct was found
-		if !found {
-			handleBadRequest(w, "The resource doesn't exist")
+		lists, unknown := resolveListReferences(resources)
+		for _, resource := range unknown {
+			slog.Warn("Notification for unknown SharePoint resource ignored",
+				"site_id", resource.SiteID, "list_id", resource.ListID, "operation", "webhook")
+		}
+
+		if len(lists) == 0 {
+			rejectNotification(span, metricsEndpointSharepoint, metrics.ReasonUnknownResource)
+			handleBadRequest(w, "None of the notified resources exist")
 			return
 		}
 
-		// Perform sync operation in a separate goroutine
-		go func() {
-			if err := syncer.SyncSharepoint(list); err != nil {
-				slog.Error("failed to sync SharePoint resource: %w", "exception", err, "operation", "webhook")
+		// Queue the syncs, duplicate requests for the same list are coalesced
+		for _, list := range lists {
+			queued := syncer.EnqueueSync(ctx, list, sync.TriggerWebhook)
+			span.AddEvent("sync.enqueued", trace.WithAttributes(attribute.String("site_id", list.SiteID),
+				attribute.String("list_id", list.ListID), attribute.Bool("coalesced", !queued)))
+			if !queued {
+				slog.Info("SharePoint sync already pending", "site_id", list.SiteID, "list_id", list.ListID, "operation", "webhook")
 			}
-		}()
+		}
 
 		w.WriteHeader(http.StatusOK)
 	}
@@ -69,37 +112,83 @@ func newSharepointHandler(syncer *sync.Syncer) http.HandlerFunc {
 
 func exctractListReference(siteID, listID string) (models.ListReference, bool) {
 	config := configuration.GetConfig()
+	return config.Sharepoint.FindList(siteID, listID)
+}
 
-	for _, list := range config.Sharepoint.Lists {
-		if list.SiteID == siteID && list.ListID == listID {
-			return list, true
+// resolveListReferences maps notified resources to configured lists, collecting the unknown ones separately.
+func resolveListReferences(resources []ListResource) ([]models.ListReference, []ListResource) {
+	var lists []models.ListReference
+	var unknown []ListResource
+
+	for _, resource := range resources {
+		if list, found := exctractListReference(resource.SiteID, resource.ListID); found {
+			lists = append(lists, list)
+		} else {
+			unknown = append(unknown, resource)
 		}
 	}
-	return models.ListReference{}, false
+	return lists, unknown
 }
 
-// extractResourceUpdateData validates the request body and extracts siteID, listID, and resource details.
-func extractResourceUpdateData(r *http.Request) (string, string, error) {
+// extractResourceUpdateData validates the request body and extracts the distinct resources of every notification.
+// Malformed notifications are logged and skipped, if none of them are valid the last validation error is returned.
+// Notifications rejected by verify are skipped, if none of the valid ones pass errUnverifiedNotifications is returned.
+func extractResourceUpdateData(r *http.Request, verify func(ResourceUpdateNotification) bool) ([]ListResource, error) {
 	var updateBody ResourceUpdateBody
-	var siteID, listID string
-	var err error
 
 	if err := json.NewDecoder(r.Body).Decode(&updateBody); err != nil {
-		return "", "", fmt.Errorf("invalid request body: %w", err)
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	if len(updateBody.Value) == 0 {
+		return nil, fmt.Errorf("request body contains no notifications")
 	}
 
+	var invalid error
+	valid := 0
+	seen := make(map[ListResource]struct{}, len(updateBody.Value))
+	resources := make([]ListResource, 0, len(updateBody.Value))
 	for _, updateUnit := range updateBody.Value {
-		if updateUnit.ResourceData.OdataType != "#Microsoft.Graph.ListItem" {
-			return "", "", fmt.Errorf("invalid data type: '%s', expected: '#Microsoft.Graph.ListItem'", updateUnit.ResourceData.OdataType)
+		resource, err := validateNotification(updateUnit)
+		if err != nil {
+			slog.Warn("Invalid SharePoint notification skipped", "subscription_id", updateUnit.SubscriptionID,
+				"resource", updateUnit.Resource, "exception", err, "operation", "webhook")
+			invalid = err
+			continue
 		}
+		valid++
 
-		siteID, listID, err = parseSharepointResource(updateUnit.Resource)
-		if err != nil {
-			return "", "", fmt.Errorf("invalid resource format: %s", err)
+		if !verify(updateUnit) {
+			continue
+		}
+
+		if _, duplicate := seen[resource]; duplicate {
+			continue
 		}
+		seen[resource] = struct{}{}
+		resources = append(resources, resource)
+	}
+
+	if len(seen) == 0 {
+		if valid == 0 {
+			return nil, invalid
+		}
+		return nil, errUnverifiedNotifications
+	}
+	return resources, nil
+}
+
+// validateNotification checks the data type and resource format of the notification and returns its resource.
+func validateNotification(updateUnit ResourceUpdateNotification) (ListResource, error) {
+	if updateUnit.ResourceData.OdataType != "#Microsoft.Graph.ListItem" {
+		return ListResource{}, fmt.Errorf("invalid data type: '%s', expected: '#Microsoft.Graph.ListItem'", updateUnit.ResourceData.OdataType)
+	}
 
+	siteID, listID, err := parseSharepointResource(updateUnit.Resource)
+	if err != nil {
+		return ListResource{}, fmt.Errorf("invalid resource format: %s", err)
 	}
-	return siteID, listID, nil
+	return ListResource{SiteID: siteID, ListID: listID}, nil
 }
 
 // parseResourceString ensures models.SharepointResourceFormat signature and returns siteID and listID.
@@ -1,19 +1,40 @@
 package webhook
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 
+	"microsoft-apps-exporter/internal/metrics"
+	"microsoft-apps-exporter/internal/models"
 	"microsoft-apps-exporter/internal/sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Lifecycle events sent by Microsoft Graph API.
+const (
+	lifecycleEventMissed                  = "missed"
+	lifecycleEventSubscriptionRemoved     = "subscriptionRemoved"
+	lifecycleEventReauthorizationRequired = "reauthorizationRequired"
 )
 
 // Request body
 type SubscriptionLifecycleBody struct {
-	Value []struct {
-		SubscriptionId string `json:"subscriptionId"`
-	} `json:"value"`
+	Value []SubscriptionLifecycleNotification `json:"value"`
+}
+
+// SubscriptionLifecycleNotification is a single lifecycle notification of the request body.
+type SubscriptionLifecycleNotification struct {
+	SubscriptionId                 string `json:"subscriptionId"`
+	ClientState                    string `json:"clientState"`
+	LifecycleEvent                 string `json:"lifecycleEvent"`
+	Resource                       string `json:"resource"`
+	SubscriptionExpirationDateTime string `json:"subscriptionExpirationDateTime"`
 }
 
 // newSubscriptionHandler handles subscription-related webhook notifications.
@@ -22,8 +43,12 @@ func newSubscriptionHandler(syncer *sync.Syncer) http.HandlerFunc {
 		slog.Info("Received subscription lifecycle notification", "operation", "webhook")
 		defer r.Body.Close()
 
+		ctx, span := startNotificationSpan(r, metricsEndpointSubscription)
+		defer span.End()
+
 		if r.Method != http.MethodPost {
-			handleMethodNotAllowed(w, fmt.Sprintf("Only POST method allowed, got: "+r.Method))
+			rejectNotification(span, metricsEndpointSubscription, metrics.ReasonMethodNotAllowed)
+			handleMethodNotAllowed(w, "Only POST method allowed, got: "+r.Method)
 			return
 		}
 
@@ -34,18 +59,43 @@ func newSubscriptionHandler(syncer *sync.Syncer) http.HandlerFunc {
 		} else if validated { // subscription creation doesnt include resource update
 			return
 		}
+		metrics.WebhookNotifications.WithLabelValues(metricsEndpointSubscription).Inc()
 
-		// Extract subscription ID from request payload
-		subscriptionID, err := extractSubscriptionLifecycleData(r)
+		// Extract lifecycle notifications from request payload
+		notifications, err := extractSubscriptionLifecycleData(r)
 		if err != nil {
+			rejectNotification(span, metricsEndpointSubscription, metrics.ReasonInvalidPayload)
 			handleBadRequest(w, fmt.Sprintf("invalid subscription payload: %v", err))
 			return
 		}
 
-		// Reauthorize the subscription
-		_, err = syncer.Graph.UpdateSubscription(subscriptionID)
-		if err != nil {
-			handleInternalError(w, fmt.Sprintf("failed to reauthorize subscription: %v", err))
+		var verified int
+		var failures []string
+		for _, notification := range notifications {
+			if !verifyClientState(syncer, r, notification.SubscriptionId, notification.ClientState, notification.Resource) {
+				continue
+			}
+			verified++
+			syncer.Graph.RecordSubscriptionNotification(ctx, notification.SubscriptionId)
+
+			span.AddEvent("lifecycle_event", trace.WithAttributes(attribute.String("subscription_id", notification.SubscriptionId),
+				attribute.String("lifecycle_event", notification.LifecycleEvent)))
+			if err := handleLifecycleEvent(ctx, syncer, notification); err != nil {
+				slog.Error("Failed to handle subscription lifecycle event", "subscription_id", notification.SubscriptionId,
+					"lifecycle_event", notification.LifecycleEvent, "exception", err, "operation", "webhook")
+				failures = append(failures, err.Error())
+			}
+		}
+
+		if verified == 0 {
+			rejectNotification(span, metricsEndpointSubscription, metrics.ReasonClientState)
+			handleForbidden(w, errUnverifiedNotifications.Error())
+			return
+		}
+
+		if len(failures) > 0 {
+			rejectNotification(span, metricsEndpointSubscription, metrics.ReasonHandlerError)
+			handleInternalError(w, strings.Join(failures, "; "))
 			return
 		}
 
@@ -53,16 +103,96 @@ func newSubscriptionHandler(syncer *sync.Syncer) http.HandlerFunc {
 	}
 }
 
-// extractSubscriptionLifecycleData extracts the subscription ID from the JSON payload.
-func extractSubscriptionLifecycleData(r *http.Request) (string, error) {
+// handleLifecycleEvent reacts to a single lifecycle notification according to its event type.
+func handleLifecycleEvent(ctx context.Context, syncer *sync.Syncer, notification SubscriptionLifecycleNotification) error {
+	subscriptionID := notification.SubscriptionId
+	slog.Info("Handling subscription lifecycle event", "subscription_id", subscriptionID,
+		"lifecycle_event", notification.LifecycleEvent, "operation", "webhook")
+
+	switch notification.LifecycleEvent {
+	case lifecycleEventMissed:
+		list, err := resolveLifecycleList(syncer, notification)
+		if err != nil {
+			return err
+		}
+
+		syncer.EnqueueResync(ctx, list, sync.TriggerWebhook)
+		return nil
+
+	case lifecycleEventSubscriptionRemoved:
+		list, err := resolveLifecycleList(syncer, notification)
+		if err != nil {
+			return err
+		}
+
+		resource := models.GenerateSharepointResourceString(list.SiteID, list.ListID)
+		if _, err := syncer.Graph.RecreateResourceSubscription(ctx, subscriptionID, resource); err != nil {
+			return err
+		}
+
+		// Changes may have been made while the subscription was removed
+		syncer.EnqueueResync(ctx, list, sync.TriggerWebhook)
+		return nil
+
+	case lifecycleEventReauthorizationRequired:
+		if err := syncer.Graph.ReauthorizeSubscription(ctx, subscriptionID); err != nil {
+			return err
+		}
+
+		if _, err := syncer.Graph.UpdateSubscription(ctx, subscriptionID); err != nil {
+			return fmt.Errorf("failed to extend reauthorized subscription: %w", err)
+		}
+		return nil
+
+	default:
+		// Extend the subscription on any other event
+		if _, err := syncer.Graph.UpdateSubscription(ctx, subscriptionID); err != nil {
+			return fmt.Errorf("failed to reauthorize subscription: %w", err)
+		}
+		return nil
+	}
+}
+
+// resolveLifecycleList finds the configured list affected by a lifecycle notification.
+// Graph omits the resource for most lifecycle events, so the subscription registry is used as a fallback.
+func resolveLifecycleList(syncer *sync.Syncer, notification SubscriptionLifecycleNotification) (models.ListReference, error) {
+	resource := notification.Resource
+	if resource == "" {
+		known, ok := syncer.Graph.SubscriptionResource(notification.SubscriptionId)
+		if !ok {
+			return models.ListReference{}, fmt.Errorf("resource of subscription %s is unknown", notification.SubscriptionId)
+		}
+		resource = known
+	}
+
+	siteID, listID, err := parseSharepointResource(resource)
+	if err != nil {
+		return models.ListReference{}, fmt.Errorf("invalid resource format: %s", err)
+	}
+
+	list, found := exctractListReference(siteID, listID)
+	if !found {
+		return models.ListReference{}, fmt.Errorf("resource %s of subscription %s is not configured", resource, notification.SubscriptionId)
+	}
+	return list, nil
+}
+
+// extractSubscriptionLifecycleData extracts every lifecycle notification from the JSON payload.
+func extractSubscriptionLifecycleData(r *http.Request) ([]SubscriptionLifecycleNotification, error) {
 	var lifecycleBody SubscriptionLifecycleBody
 	if err := json.NewDecoder(r.Body).Decode(&lifecycleBody); err != nil {
-		return "", fmt.Errorf("failed to parse JSON: %w", err)
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
 	}
 
-	if len(lifecycleBody.Value) == 0 || lifecycleBody.Value[0].SubscriptionId == "" {
-		return "", fmt.Errorf("missing subscriptionId in the lifecycleBody")
+	if len(lifecycleBody.Value) == 0 {
+		return nil, fmt.Errorf("missing notifications in the lifecycleBody")
+	}
+
+	for i, notification := range lifecycleBody.Value {
+		if notification.SubscriptionId == "" {
+			return nil, fmt.Errorf("missing subscriptionId in the lifecycleBody value %d", i)
+		}
 	}
 
-	return lifecycleBody.Value[0].SubscriptionId, nil
+	return lifecycleBody.Value, nil
 }
@@ -31,6 +31,10 @@ func HandleBadRequest(w http.ResponseWriter, message string) {
 	handleBadRequest(w, message)
 }
 
+func HandleForbidden(w http.ResponseWriter, message string) {
+	handleForbidden(w, message)
+}
+
 func HandleInternalError(w http.ResponseWriter, message string) {
 	handleInternalError(w, message)
 }
@@ -39,14 +43,24 @@ func ExctractListReference(siteID, listID string) (models.ListReference, bool) {
 	return exctractListReference(siteID, listID)
 }
 
-func ExtractResourceUpdateData(r *http.Request) (string, string, error) {
-	return extractResourceUpdateData(r)
+func ExtractResourceUpdateData(r *http.Request, verify func(ResourceUpdateNotification) bool) ([]ListResource, error) {
+	return extractResourceUpdateData(r, verify)
+}
+
+var ErrUnverifiedNotifications = errUnverifiedNotifications
+
+func ResolveListReferences(resources []ListResource) ([]models.ListReference, []ListResource) {
+	return resolveListReferences(resources)
 }
 
 func ParseSharepointResource(resource string) (string, string, error) {
 	return parseSharepointResource(resource)
 }
 
-func ExtractSubscriptionLifecycleData(r *http.Request) (string, error) {
+func ExtractSubscriptionLifecycleData(r *http.Request) ([]SubscriptionLifecycleNotification, error) {
 	return extractSubscriptionLifecycleData(r)
 }
+
+func ResolveLifecycleList(syncer *sync.Syncer, notification SubscriptionLifecycleNotification) (models.ListReference, error) {
+	return resolveLifecycleList(syncer, notification)
+}
@@ -4,8 +4,23 @@
 // This file is only included in builds with the "testing" tag.
 package configuration
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
 func ResetConfig() {
 	once = sync.Once{}
 }
+
+func GetEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	return getEnvDuration(key, defaultValue)
+}
+
+func GetEnvInt(key string, defaultValue int) int {
+	return getEnvInt(key, defaultValue)
+}
+
+func GetEnvBool(key string, defaultValue bool) bool {
+	return getEnvBool(key, defaultValue)
+}
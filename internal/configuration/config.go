@@ -1,11 +1,15 @@
 package configuration
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"microsoft-apps-exporter/internal/models"
+	"microsoft-apps-exporter/internal/transform"
 	"os"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -18,6 +22,13 @@ type Configuration struct {
 	GRAPH_CLIENT_SECRET string
 	GRAPH_APP_SCOPES    string
 
+	GRAPH_RETRY_MAX_RETRIES int
+	GRAPH_RETRY_BASE_DELAY  time.Duration
+	GRAPH_RETRY_MAX_DELAY   time.Duration
+	GRAPH_RETRY_BUDGET      time.Duration
+
+	GRAPH_REQUEST_TIMEOUT time.Duration
+
 	Sharepoint *models.SharepointResource `mapstructure:"sharepoint"`
 
 	DB_HOST     string
@@ -27,11 +38,70 @@ type Configuration struct {
 	DB_NAME     string
 	DB_DSN      string
 
+	DB_QUERY_TIMEOUT  time.Duration
+	DB_BULK_THRESHOLD int
+
 	WEBHOOK_LISTEN_IP         string
 	WEBHOOK_LISTEN_PORT       string
 	WEBHOOK_EXTERNAL_BASE_URL string
+	WEBHOOK_CLIENT_STATE      string
+
+	SUBSCRIPTION_EXPIRY           time.Duration
+	SUBSCRIPTION_UPDATE_EXPIRY    time.Duration
+	SUBSCRIPTION_RENEWAL_MARGIN   time.Duration
+	SUBSCRIPTION_RENEWAL_INTERVAL time.Duration
+	SUBSCRIPTION_CLEANUP_DRY_RUN  bool
+
+	SYNC_INTERVAL time.Duration
+	SYNC_JITTER   time.Duration
+
+	SYNC_MAX_CONCURRENCY int
+	SYNC_TIMEOUT         time.Duration
+
+	SYNC_RETRY_INTERVAL     time.Duration
+	SYNC_RETRY_BASE_DELAY   time.Duration
+	SYNC_RETRY_MAX_DELAY    time.Duration
+	SYNC_RETRY_MAX_ATTEMPTS int
+
+	SYNC_SCHEMA_DRIFT_POLICY string
+
+	SHUTDOWN_GRACE_PERIOD time.Duration
+
+	OTEL_TRACES_EXPORTER string
+	OTEL_TRACES_FILE     string
 }
 
+const (
+	defaultGraphRetryMaxRetries = 5
+	defaultGraphRetryBaseDelay  = time.Second
+	defaultGraphRetryMaxDelay   = time.Minute
+	defaultGraphRetryBudget     = 5 * time.Minute
+
+	defaultGraphRequestTimeout = 10 * time.Minute
+	defaultDbQueryTimeout      = time.Minute
+	defaultDbBulkThreshold     = 1000
+
+	defaultSubscriptionExpiry          = 48 * time.Hour
+	defaultSubscriptionUpdateExpiry    = 72 * time.Hour
+	defaultSubscriptionRenewalMargin   = 12 * time.Hour
+	defaultSubscriptionRenewalInterval = 15 * time.Minute
+
+	defaultSyncInterval = time.Hour
+	defaultSyncJitter   = time.Minute
+
+	defaultSyncMaxConcurrency = 4
+	defaultSyncTimeout        = time.Hour
+
+	defaultSyncRetryInterval    = 30 * time.Second
+	defaultSyncRetryBaseDelay   = time.Minute
+	defaultSyncRetryMaxDelay    = time.Hour
+	defaultSyncRetryMaxAttempts = 8
+
+	defaultSyncSchemaDriftPolicy = "warn"
+
+	defaultShutdownGracePeriod = 30 * time.Second
+)
+
 var (
 	config Configuration
 	once   sync.Once
@@ -48,6 +118,25 @@ func GetConfig() Configuration {
 	return config
 }
 
+// Validate checks the transforms, filters and time zones of the configured lists,
+// so a mistake fails the startup rather than every sync.
+func (c Configuration) Validate() error {
+	if c.Sharepoint == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, list := range c.Sharepoint.Lists {
+		if _, err := transform.NewPipeline(list); err != nil {
+			errs = append(errs, fmt.Errorf("list %s: %w", list.ListID, err))
+		}
+		if _, err := list.Location(); err != nil {
+			errs = append(errs, fmt.Errorf("list %s: %w", list.ListID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // loadResourcesYaml reads YAML configuration from resources.yaml.
 func loadResourcesYaml() {
 	viper.SetConfigName("resources")
@@ -70,15 +159,106 @@ func loadEnvVars() {
 	config.GRAPH_CLIENT_SECRET = os.Getenv("GRAPH_CLIENT_SECRET")
 	config.GRAPH_APP_SCOPES = os.Getenv("GRAPH_APP_SCOPES")
 
+	config.GRAPH_RETRY_MAX_RETRIES = getEnvInt("GRAPH_RETRY_MAX_RETRIES", defaultGraphRetryMaxRetries)
+	config.GRAPH_RETRY_BASE_DELAY = getEnvDuration("GRAPH_RETRY_BASE_DELAY", defaultGraphRetryBaseDelay)
+	config.GRAPH_RETRY_MAX_DELAY = getEnvDuration("GRAPH_RETRY_MAX_DELAY", defaultGraphRetryMaxDelay)
+	config.GRAPH_RETRY_BUDGET = getEnvDuration("GRAPH_RETRY_BUDGET", defaultGraphRetryBudget)
+
+	config.GRAPH_REQUEST_TIMEOUT = getEnvDuration("GRAPH_REQUEST_TIMEOUT", defaultGraphRequestTimeout)
+
 	config.DB_HOST = os.Getenv("DB_HOST")
 	config.DB_PORT = os.Getenv("DB_PORT")
 	config.DB_USER = os.Getenv("DB_USER")
 	config.DB_PASSWORD = os.Getenv("DB_PASSWORD")
 	config.DB_NAME = os.Getenv("DB_NAME")
 
+	config.DB_QUERY_TIMEOUT = getEnvDuration("DB_QUERY_TIMEOUT", defaultDbQueryTimeout)
+	config.DB_BULK_THRESHOLD = getEnvInt("DB_BULK_THRESHOLD", defaultDbBulkThreshold)
+
 	config.WEBHOOK_LISTEN_IP = os.Getenv("WEBHOOK_LISTEN_IP")
 	config.WEBHOOK_LISTEN_PORT = os.Getenv("WEBHOOK_LISTEN_PORT")
 	config.WEBHOOK_EXTERNAL_BASE_URL = os.Getenv("WEBHOOK_EXTERNAL_BASE_URL")
+	config.WEBHOOK_CLIENT_STATE = os.Getenv("WEBHOOK_CLIENT_STATE")
+
+	config.SUBSCRIPTION_EXPIRY = getEnvDuration("SUBSCRIPTION_EXPIRY", defaultSubscriptionExpiry)
+	config.SUBSCRIPTION_UPDATE_EXPIRY = getEnvDuration("SUBSCRIPTION_UPDATE_EXPIRY", defaultSubscriptionUpdateExpiry)
+	config.SUBSCRIPTION_RENEWAL_MARGIN = getEnvDuration("SUBSCRIPTION_RENEWAL_MARGIN", defaultSubscriptionRenewalMargin)
+	config.SUBSCRIPTION_RENEWAL_INTERVAL = getEnvDuration("SUBSCRIPTION_RENEWAL_INTERVAL", defaultSubscriptionRenewalInterval)
+	config.SUBSCRIPTION_CLEANUP_DRY_RUN = getEnvBool("SUBSCRIPTION_CLEANUP_DRY_RUN", false)
+
+	config.SYNC_INTERVAL = getEnvDuration("SYNC_INTERVAL", defaultSyncInterval)
+	config.SYNC_JITTER = getEnvDuration("SYNC_JITTER", defaultSyncJitter)
+
+	config.SYNC_MAX_CONCURRENCY = getEnvInt("SYNC_MAX_CONCURRENCY", defaultSyncMaxConcurrency)
+	config.SYNC_TIMEOUT = getEnvDuration("SYNC_TIMEOUT", defaultSyncTimeout)
+
+	config.SYNC_RETRY_INTERVAL = getEnvDuration("SYNC_RETRY_INTERVAL", defaultSyncRetryInterval)
+	config.SYNC_RETRY_BASE_DELAY = getEnvDuration("SYNC_RETRY_BASE_DELAY", defaultSyncRetryBaseDelay)
+	config.SYNC_RETRY_MAX_DELAY = getEnvDuration("SYNC_RETRY_MAX_DELAY", defaultSyncRetryMaxDelay)
+	config.SYNC_RETRY_MAX_ATTEMPTS = getEnvInt("SYNC_RETRY_MAX_ATTEMPTS", defaultSyncRetryMaxAttempts)
+
+	config.SYNC_SCHEMA_DRIFT_POLICY = getEnvString("SYNC_SCHEMA_DRIFT_POLICY", defaultSyncSchemaDriftPolicy)
+
+	config.SHUTDOWN_GRACE_PERIOD = getEnvDuration("SHUTDOWN_GRACE_PERIOD", defaultShutdownGracePeriod)
+
+	config.OTEL_TRACES_EXPORTER = os.Getenv("OTEL_TRACES_EXPORTER")
+	config.OTEL_TRACES_FILE = os.Getenv("OTEL_TRACES_FILE")
+}
+
+// getEnvString reads a string environment variable, falling back to the default when unset.
+func getEnvString(key string, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvDuration parses a duration environment variable, falling back to the default when unset or invalid.
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		slog.Error("Failed to parse duration, using default", "key", key, "value", value,
+			"default", defaultValue, "error", err, "operation", "config")
+		return defaultValue
+	}
+	return duration
+}
+
+// getEnvInt parses an integer environment variable, falling back to the default when unset or invalid.
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		slog.Error("Failed to parse integer, using default", "key", key, "value", value,
+			"default", defaultValue, "error", err, "operation", "config")
+		return defaultValue
+	}
+	return number
+}
+
+// getEnvBool parses a boolean environment variable, falling back to the default when unset or invalid.
+func getEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	flag, err := strconv.ParseBool(value)
+	if err != nil {
+		slog.Error("Failed to parse boolean, using default", "key", key, "value", value,
+			"default", defaultValue, "error", err, "operation", "config")
+		return defaultValue
+	}
+	return flag
 }
 
 // buildPostgresDSN constructs the connection string for PostgreSQL.
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"microsoft-apps-exporter/internal/models"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// bulkStagingTable is the temporary table list items are copied into before being merged into the target table.
+const bulkStagingTable = "list_items_staging"
+
+// useBulk reports whether the number of rows reaches the bulk threshold.
+func (db *Database) useBulk(rows int) bool {
+	return db.BulkThreshold > 0 && rows >= db.BulkThreshold
+}
+
+// writeListItems upserts the items row by row, or with COPY once their number reaches the bulk threshold.
+func (db *Database) writeListItems(ctx context.Context, tx *sql.Tx, table string, columnsMap map[string]string, listItems []models.ListItem) (models.ChangeCounts, error) {
+	if db.useBulk(len(listItems)) {
+		return bulkUpsertListItems(ctx, tx, table, columnsMap, listItems)
+	}
+	return upsertListItems(ctx, tx, table, columnsMap, listItems)
+}
+
+// removeListItems deletes the items row by row, or with a single statement once their number reaches the bulk threshold.
+// It returns the number of deleted rows.
+func (db *Database) removeListItems(ctx context.Context, tx *sql.Tx, table string, IDs []string) (int, error) {
+	if db.useBulk(len(IDs)) {
+		return bulkDeleteListItems(ctx, tx, table, IDs)
+	}
+
+	var deleted int
+	for _, id := range IDs {
+		count, err := deleteListItem(ctx, tx, table, id)
+		if err != nil {
+			return deleted, err
+		}
+		deleted += count
+	}
+	return deleted, nil
+}
+
+// bulkUpsertListItems copies the items into a staging table dropped on commit and merges them into the target table.
+// Items repeated in the changes are copied once, the last occurrence wins like with row by row upserts.
+func bulkUpsertListItems(ctx context.Context, tx *sql.Tx, table string, columnsMap map[string]string, listItems []models.ListItem) (models.ChangeCounts, error) {
+	var counts models.ChangeCounts
+
+	metadataColumns := models.ListItemMetadata{}.DbColumns()
+	fieldsColumns := extractKeys(columnsMap)
+	allColumns := append(metadataColumns, fieldsColumns...)
+
+	query := fmt.Sprintf("CREATE TEMP TABLE %s (LIKE %s INCLUDING DEFAULTS) ON COMMIT DROP;", bulkStagingTable, table)
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return counts, fmt.Errorf("failed to create staging table: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(bulkStagingTable, allColumns...))
+	if err != nil {
+		return counts, fmt.Errorf("failed to start copy: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, listItem := range lastOccurrences(listItems) {
+		values := append(listItem.Metadata.AsArray(), mapFieldValues(listItem.MappedFields, columnsMap, fieldsColumns)...)
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			return counts, fmt.Errorf("item_id \"%s\": %w", listItem.Metadata.ID, err)
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil { // Flush the buffered rows
+		return counts, fmt.Errorf("failed to copy: %w", err)
+	}
+
+	columns := strings.Join(allColumns, ", ")
+	query = fmt.Sprintf(`
+		WITH merged AS (
+			INSERT INTO %s (%s) SELECT %s FROM %s
+			ON CONFLICT (id) DO UPDATE SET %s
+			RETURNING (xmax = 0) AS inserted
+		)
+		SELECT COUNT(*) FILTER (WHERE inserted), COUNT(*) FILTER (WHERE NOT inserted) FROM merged;`,
+		table, columns, columns, bulkStagingTable, strings.Join(buildExcludedClauses(allColumns[1:]), ", "))
+
+	if err := tx.QueryRowContext(ctx, query).Scan(&counts.Inserted, &counts.Updated); err != nil {
+		return counts, fmt.Errorf("failed to merge staging table: %w", err)
+	}
+	return counts, nil
+}
+
+// bulkDeleteListItems deletes the items with a single statement and returns the number of deleted rows.
+func bulkDeleteListItems(ctx context.Context, tx *sql.Tx, table string, IDs []string) (int, error) {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE id = ANY($1);`, table)
+
+	result, err := tx.ExecContext(ctx, query, pq.Array(IDs))
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	return int(deleted), err
+}
+
+// lastOccurrences returns the items without the earlier occurrences of repeated IDs, preserving their order.
+func lastOccurrences(listItems []models.ListItem) []models.ListItem {
+	last := make(map[string]int, len(listItems))
+	for i, listItem := range listItems {
+		last[listItem.Metadata.ID] = i
+	}
+	if len(last) == len(listItems) {
+		return listItems
+	}
+
+	unique := make([]models.ListItem, 0, len(last))
+	for i, listItem := range listItems {
+		if last[listItem.Metadata.ID] == i {
+			unique = append(unique, listItem)
+		}
+	}
+	return unique
+}
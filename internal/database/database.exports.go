@@ -5,6 +5,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"microsoft-apps-exporter/internal/models"
 )
@@ -21,26 +22,26 @@ func MapFieldValues(mappedFields models.ListItemMappedFields, columnsMap map[str
 	return mapFieldValues(mappedFields, columnsMap, fieldsColumns)
 }
 
-func BuildUpdateClauses(metadataColumns []string, columnsMap map[string]string, listItem models.ListItem) ([]string, []interface{}) {
-	return buildUpdateClauses(metadataColumns, columnsMap, listItem)
+func BuildExcludedClauses(columns []string) []string {
+	return buildExcludedClauses(columns)
 }
 
-func ScanListItem(rows *sql.Rows) (models.ListItem, error) {
-	return scanListItem(rows)
+func LastOccurrences(listItems []models.ListItem) []models.ListItem {
+	return lastOccurrences(listItems)
 }
 
-func SegregateColumns(columns []string, values []interface{}, metadataFields []string) (map[string]interface{}, map[string]interface{}) {
-	return segregateColumns(columns, values, metadataFields)
+func (db *Database) WithTransaction(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	return db.withTransaction(ctx, fn)
 }
 
-func MarshalJSON(data interface{}) []byte {
-	return marshalJSON(data)
+func (db *Database) UseBulk(rows int) bool {
+	return db.useBulk(rows)
 }
 
-func Contains(slice []string, item string) bool {
-	return contains(slice, item)
+func (db *Database) WriteListItems(ctx context.Context, tx *sql.Tx, table string, columnsMap map[string]string, listItems []models.ListItem) (models.ChangeCounts, error) {
+	return db.writeListItems(ctx, tx, table, columnsMap, listItems)
 }
 
-func (db *Database) WithTransaction(fn func(tx *sql.Tx) error) error {
-	return db.withTransaction(fn)
+func (db *Database) RemoveListItems(ctx context.Context, tx *sql.Tx, table string, IDs []string) (int, error) {
+	return db.removeListItems(ctx, tx, table, IDs)
 }
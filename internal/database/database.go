@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log/slog"
@@ -11,12 +12,14 @@ import (
 )
 
 type Database struct {
-	Connection *sql.DB
+	Connection    *sql.DB
+	QueryTimeout  time.Duration // Deadline of every query or transaction, none when zero
+	BulkThreshold int           // Number of list items from which they are written with COPY, disabled when zero
 }
 
 // NewDatabase initializes a new Database instance and establishes a connection to the PostgreSQL database.
 // It also sets up the database schema if it doesn't already exist.
-func NewDatabase() (*Database, error) {
+func NewDatabase(ctx context.Context) (*Database, error) {
 	config := configuration.GetConfig()
 
 	db, err := sql.Open("postgres", config.DB_DSN)
@@ -28,12 +31,12 @@ func NewDatabase() (*Database, error) {
 	db.SetMaxIdleConns(5)
 	db.SetConnMaxLifetime(5 * time.Minute)
 
-	if err := db.Ping(); err != nil {
+	if err := db.PingContext(ctx); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	database := &Database{Connection: db}
+	database := &Database{Connection: db, QueryTimeout: config.DB_QUERY_TIMEOUT, BulkThreshold: config.DB_BULK_THRESHOLD}
 
 	slog.Info("Database connection established", "operation", "database")
 	return database, nil
@@ -1,25 +1,39 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 )
 
-// withTransaction executes a function within a database transaction.
-// It handles rollback and commit logic automatically.
-func (db *Database) withTransaction(fn func(tx *sql.Tx) error) error {
-	tx, err := db.Connection.Begin()
+// withTransaction executes a function within a database transaction bounded by the query timeout.
+// It handles rollback and commit logic automatically, the transaction is rolled back when the context is cancelled.
+func (db *Database) withTransaction(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return db.inTransaction(ctx, fn)
+}
+
+// inTransaction executes a function within a database transaction bounded only by the context,
+// for transactions running more statements than the query timeout is meant for.
+func (db *Database) inTransaction(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) (err error) {
+	tx, err := db.Connection.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
 	defer handleTxRollback(tx, &err)
 
-	if err = fn(tx); err != nil {
-		return err
-	}
+	return fn(ctx, tx)
+}
 
-	return nil
+// withTimeout bounds the context by the query timeout, if any.
+func (db *Database) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if db.QueryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, db.QueryTimeout)
 }
 
 // handleTxRollback handles the transaction rollback and commit logic.
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+/*
+Schema
+*/
+
+// TableExists reports whether the table exists in the search path of the connection.
+func (db *Database) TableExists(ctx context.Context, table string) (bool, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	var exists bool
+	err := db.Connection.QueryRowContext(ctx, `SELECT to_regclass($1) IS NOT NULL;`, table).Scan(&exists)
+	return exists, err
+}
+
+// ApplySchema executes a DDL statement within a transaction.
+func (db *Database) ApplySchema(ctx context.Context, statement string) error {
+	return db.withTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, statement)
+		return err
+	})
+}
+
+// GetTableColumns returns the columns of the table with their information_schema data type, keyed by name.
+// The table is resolved through the search path, it has no columns when it does not exist.
+func (db *Database) GetTableColumns(ctx context.Context, table string) (map[string]string, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT c.column_name, c.data_type
+	FROM information_schema.columns c
+	JOIN pg_class t ON t.oid = to_regclass($1)
+	WHERE c.table_schema = t.relnamespace::regnamespace::text AND c.table_name = t.relname;`
+
+	rows, err := db.Connection.QueryContext(ctx, query, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]string)
+	for rows.Next() {
+		var name, dataType string
+		if err := rows.Scan(&name, &dataType); err != nil {
+			return nil, err
+		}
+		columns[name] = dataType
+	}
+
+	return columns, rows.Err()
+}
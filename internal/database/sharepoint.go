@@ -6,13 +6,18 @@ import (
 	"fmt"
 	"microsoft-apps-exporter/internal/models"
 	"strings"
+
+	"github.com/lib/pq"
 )
 
 /*
 Lists
 */
 
-func (db *Database) GetList(ID string) ([]models.ListMetadata, error) {
+func (db *Database) GetList(ctx context.Context, ID string) ([]models.ListMetadata, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
 	query := `
 	SELECT 
 		id, site_id, etag, name, display_name, delta_link
@@ -21,7 +26,7 @@ func (db *Database) GetList(ID string) ([]models.ListMetadata, error) {
 
 	var metadata models.ListMetadata
 
-	err := db.Connection.QueryRowContext(context.Background(), query, ID).Scan(
+	err := db.Connection.QueryRowContext(ctx, query, ID).Scan(
 		&metadata.ID,
 		&metadata.SiteID,
 		&metadata.ETag,
@@ -39,7 +44,7 @@ func (db *Database) GetList(ID string) ([]models.ListMetadata, error) {
 	return []models.ListMetadata{metadata}, nil
 }
 
-func (db *Database) InsertLists(m *[]models.ListMetadata) error {
+func (db *Database) InsertLists(ctx context.Context, m *[]models.ListMetadata) error {
 	query := `
 		INSERT INTO sharepoint_lists (
 			id, site_id, etag, name, display_name, delta_link
@@ -47,9 +52,9 @@ func (db *Database) InsertLists(m *[]models.ListMetadata) error {
 			$1, $2, $3, $4, $5, $6
 		);`
 
-	return db.withTransaction(func(tx *sql.Tx) error {
+	return db.withTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
 		for _, metadata := range *m {
-			_, err := tx.ExecContext(context.Background(), query,
+			_, err := tx.ExecContext(ctx, query,
 				metadata.ID,
 				metadata.SiteID,
 				metadata.ETag,
@@ -65,7 +70,47 @@ func (db *Database) InsertLists(m *[]models.ListMetadata) error {
 	})
 }
 
-func (db *Database) UpdateListIgnoreDelta(metadata models.ListMetadata) error {
+// UpsertLists inserts the lists or updates their metadata when they already exist, keeping their delta links.
+// It returns the numbers of inserted and updated lists.
+func (db *Database) UpsertLists(ctx context.Context, lists []models.ListMetadata) (counts models.ChangeCounts, err error) {
+	query := `
+		INSERT INTO sharepoint_lists (
+			id, site_id, etag, name, display_name
+		) VALUES (
+			$1, $2, $3, $4, $5
+		)
+		ON CONFLICT (id) DO UPDATE SET
+			site_id = EXCLUDED.site_id,
+			etag = EXCLUDED.etag,
+			name = EXCLUDED.name,
+			display_name = EXCLUDED.display_name
+		RETURNING (xmax = 0) AS inserted;`
+
+	err = db.withTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		for _, metadata := range lists {
+			var inserted bool
+			err := tx.QueryRowContext(ctx, query,
+				metadata.ID,
+				metadata.SiteID,
+				metadata.ETag,
+				metadata.Name,
+				metadata.DisplayName,
+			).Scan(&inserted)
+			if err != nil {
+				return err
+			}
+			if inserted {
+				counts.Inserted++
+			} else {
+				counts.Updated++
+			}
+		}
+		return nil
+	})
+	return counts, err
+}
+
+func (db *Database) UpdateListIgnoreDelta(ctx context.Context, metadata models.ListMetadata) error {
 	query := `
 		UPDATE sharepoint_lists
 		SET 
@@ -75,8 +120,8 @@ func (db *Database) UpdateListIgnoreDelta(metadata models.ListMetadata) error {
 			display_name = $5
 		WHERE sharepoint_lists.id = $1;
 	`
-	return db.withTransaction(func(tx *sql.Tx) error {
-		_, err := tx.ExecContext(context.Background(), query,
+	return db.withTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query,
 			metadata.ID,
 			metadata.SiteID,
 			metadata.ETag,
@@ -87,18 +132,21 @@ func (db *Database) UpdateListIgnoreDelta(metadata models.ListMetadata) error {
 	})
 }
 
-func (db *Database) DeleteList(ID string) error {
+func (db *Database) DeleteList(ctx context.Context, ID string) error {
 	query := `
 		DELETE FROM sharepoint_lists
 		WHERE id = $1;
 	`
-	return db.withTransaction(func(tx *sql.Tx) error {
-		_, err := tx.ExecContext(context.Background(), query, ID)
+	return db.withTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query, ID)
 		return err
 	})
 }
 
-func (db *Database) GetDeltaLink(listID string) (*string, error) {
+func (db *Database) GetDeltaLink(ctx context.Context, listID string) (*string, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT delta_link
 		FROM sharepoint_lists
@@ -106,7 +154,7 @@ func (db *Database) GetDeltaLink(listID string) (*string, error) {
 
 	var deltaLink sql.NullString
 
-	err := db.Connection.QueryRowContext(context.Background(), query, listID).Scan(&deltaLink)
+	err := db.Connection.QueryRowContext(ctx, query, listID).Scan(&deltaLink)
 	if err != nil {
 		return nil, err
 	}
@@ -118,96 +166,179 @@ func (db *Database) GetDeltaLink(listID string) (*string, error) {
 	return nil, nil // Return nil if delta_link is NULL
 }
 
-func (db *Database) SaveDeltaLink(listID, deltaLink string) error {
-	query := `
-		UPDATE sharepoint_lists 
-		SET delta_link = $2 
-		WHERE id = $1;`
-
-	return db.withTransaction(func(tx *sql.Tx) error {
-		_, err := tx.ExecContext(context.Background(), query, listID, deltaLink)
-		return err
+func (db *Database) DeleteDeltaLink(ctx context.Context, listID string) error {
+	return db.withTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return setDeltaLink(ctx, tx, listID, nil)
 	})
 }
 
-func (db *Database) DeleteDeltaLink(listID string) error {
+// setDeltaLink stores the delta link of the list within the transaction, nil clears it.
+func setDeltaLink(ctx context.Context, tx *sql.Tx, listID string, deltaLink *string) error {
 	query := `
 		UPDATE sharepoint_lists 
-		SET delta_link = NULL 
+		SET delta_link = $2 
 		WHERE id = $1;`
 
-	return db.withTransaction(func(tx *sql.Tx) error {
-		_, err := tx.ExecContext(context.Background(), query, listID)
-		return err
-	})
+	_, err := tx.ExecContext(ctx, query, listID, deltaLink)
+	return err
 }
 
 /*
 List Items
 */
 
-func (db *Database) GetListItems(table, siteID, listID string) (*[]models.ListItem, error) {
-	query := fmt.Sprintf(`SELECT * FROM %s WHERE site_id = $1 AND list_id = $2;`, table)
+// ApplyListItemChanges applies the item changes of a batch of pages of a list sync along with the progress of the sync
+// in a single transaction, so a failed batch leaves both the items and the position of the sync untouched.
+// Intermediate batches store a checkpoint to resume from, the last one stores the delta link, a nil one clears it,
+// and removes the checkpoint. A full sync records the items of every batch as seen, so it still knows them
+// once resumed, and deletes the items it has not seen with the last batch.
+// The changes are idempotent, so a replayed delta page or a concurrent sync does not fail the list.
+// Large changes are loaded with COPY, see BulkThreshold.
+// The transaction is bounded by the context rather than the query timeout, as a page may be large.
+func (db *Database) ApplyListItemChanges(ctx context.Context, list models.ListReference, changes models.ListItemChanges,
+	progress models.SyncProgress) (counts models.ChangeCounts, err error) {
+	table, columnsMap := list.DbTableName, list.ColumnsMap
+
+	err = db.inTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		counts, err = db.writeListItems(ctx, tx, table, columnsMap, changes.Upsert)
+		if err != nil {
+			return fmt.Errorf("failed to upsert: %w", err)
+		}
+
+		counts.Deleted, err = db.removeListItems(ctx, tx, table, changes.Delete)
+		if err != nil {
+			return fmt.Errorf("failed to delete: %w", err)
+		}
+
+		if progress.Full {
+			if progress.First {
+				// A new full sync starts from scratch, the items seen by an abandoned one are not relevant
+				if err := deleteSeenListItems(ctx, tx, list.SiteID, list.ListID); err != nil {
+					return fmt.Errorf("failed to clear seen items: %w", err)
+				}
+			}
+			if err := saveSeenListItems(ctx, tx, list.SiteID, list.ListID, progress.SeenIDs); err != nil {
+				return fmt.Errorf("failed to save seen items: %w", err)
+			}
+		}
 
-	rows, err := db.Connection.QueryContext(context.Background(), query, siteID, listID)
+		if progress.NextLink != nil {
+			checkpoint := models.SyncCheckpoint{SiteID: list.SiteID, ListID: list.ListID, NextLink: *progress.NextLink,
+				Full: progress.Full, Pages: progress.Pages}
+			if err := saveSyncCheckpoint(ctx, tx, checkpoint); err != nil {
+				return fmt.Errorf("failed to save checkpoint: %w", err)
+			}
+			return nil
+		}
+
+		if progress.Full {
+			pruned, err := pruneListItems(ctx, tx, table, list.SiteID, list.ListID)
+			if err != nil {
+				return fmt.Errorf("failed to delete unseen items: %w", err)
+			}
+			counts.Deleted += pruned
+
+			if err := deleteSeenListItems(ctx, tx, list.SiteID, list.ListID); err != nil {
+				return fmt.Errorf("failed to clear seen items: %w", err)
+			}
+		}
+
+		if err := setDeltaLink(ctx, tx, list.ListID, progress.DeltaLink); err != nil {
+			return fmt.Errorf("failed to save delta link: %w", err)
+		}
+		if err := deleteSyncCheckpoint(ctx, tx, list.SiteID, list.ListID); err != nil {
+			return fmt.Errorf("failed to delete checkpoint: %w", err)
+		}
+		return nil
+	})
+	return counts, err
+}
+
+// GetListItemETags returns the etags of the items of the list with the given IDs, keyed by ID.
+func (db *Database) GetListItemETags(ctx context.Context, table, siteID, listID string, IDs []string) (map[string]string, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`SELECT id, etag FROM %s WHERE site_id = $1 AND list_id = $2 AND id = ANY($3);`, table)
+
+	rows, err := db.Connection.QueryContext(ctx, query, siteID, listID, pq.Array(IDs))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var listItems []models.ListItem
+	etags := make(map[string]string, len(IDs))
 	for rows.Next() {
-		listItem, err := scanListItem(rows)
-		if err != nil {
-			return nil, fmt.Errorf("item_id \"%s\": %w", listItem.Metadata.ID, err)
+		var id string
+		var etag sql.NullString
+		if err := rows.Scan(&id, &etag); err != nil {
+			return nil, err
 		}
-		listItems = append(listItems, listItem)
+		etags[id] = etag.String
+	}
+
+	return etags, rows.Err()
+}
+
+// pruneListItems deletes the items of the list not seen by the full sync and returns the number of deleted rows.
+func pruneListItems(ctx context.Context, tx *sql.Tx, table, siteID, listID string) (int, error) {
+	query := fmt.Sprintf(`
+		DELETE FROM %s t
+		WHERE t.site_id = $1 AND t.list_id = $2
+		AND NOT EXISTS (
+			SELECT 1 FROM sync_seen_items s
+			WHERE s.site_id = $1 AND s.list_id = $2 AND s.item_id = t.id
+		);`, table)
+
+	result, err := tx.ExecContext(ctx, query, siteID, listID)
+	if err != nil {
+		return 0, err
 	}
 
-	return &listItems, nil
+	deleted, err := result.RowsAffected()
+	return int(deleted), err
 }
 
-func (db *Database) InsertListItems(table string, columnsMap map[string]string, listItems *[]models.ListItem) error {
+// upsertListItems inserts the items or updates every column of the existing ones.
+func upsertListItems(ctx context.Context, tx *sql.Tx, table string, columnsMap map[string]string, listItems []models.ListItem) (models.ChangeCounts, error) {
+	var counts models.ChangeCounts
+	if len(listItems) == 0 {
+		return counts, nil
+	}
+
 	metadataColumns := models.ListItemMetadata{}.DbColumns()
 	fieldsColumns := extractKeys(columnsMap)
 	allColumns := append(metadataColumns, fieldsColumns...)
 	placeholders := generatePlaceholders(len(allColumns))
 
-	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s);", table, strings.Join(allColumns, ", "), strings.Join(placeholders, ", "))
-
-	return db.withTransaction(func(tx *sql.Tx) error {
-		for _, listItem := range *listItems {
-			values := append(listItem.Metadata.AsArray(), mapFieldValues(listItem.MappedFields, columnsMap, fieldsColumns)...)
-			if _, err := tx.ExecContext(context.Background(), query, values...); err != nil {
-				return fmt.Errorf("item_id \"%s\": %w", listItem.Metadata.ID, err)
-			}
-		}
-		return nil
-	})
-}
-
-func (db *Database) UpdateListItem(table string, columnsMap map[string]string, listItem models.ListItem) error {
-	metadataColumns := listItem.Metadata.DbColumns()
-	setClauses, values := buildUpdateClauses(metadataColumns, columnsMap, listItem)
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (id) DO UPDATE SET %s RETURNING (xmax = 0) AS inserted;",
+		table, strings.Join(allColumns, ", "), strings.Join(placeholders, ", "), strings.Join(buildExcludedClauses(allColumns[1:]), ", "))
 
-	query := fmt.Sprintf(`UPDATE %s SET %s WHERE id = $1;`, table, strings.Join(setClauses, ", "))
-	values = append([]interface{}{listItem.Metadata.ID}, values...)
+	for _, listItem := range listItems {
+		values := append(listItem.Metadata.AsArray(), mapFieldValues(listItem.MappedFields, columnsMap, fieldsColumns)...)
 
-	return db.withTransaction(func(tx *sql.Tx) error {
-		if _, err := tx.ExecContext(context.Background(), query, values...); err != nil {
-			return fmt.Errorf("item_id \"%s\": %w", listItem.Metadata.ID, err)
+		var inserted bool
+		if err := tx.QueryRowContext(ctx, query, values...).Scan(&inserted); err != nil {
+			return counts, fmt.Errorf("item_id \"%s\": %w", listItem.Metadata.ID, err)
 		}
-		return nil
-	})
+		if inserted {
+			counts.Inserted++
+		} else {
+			counts.Updated++
+		}
+	}
+	return counts, nil
 }
 
-func (db *Database) DeleteListItem(table, ID string) error {
+// deleteListItem deletes the item and returns the number of deleted rows.
+func deleteListItem(ctx context.Context, tx *sql.Tx, table, ID string) (int, error) {
 	query := fmt.Sprintf(`DELETE FROM %s WHERE id = $1;`, table)
 
-	return db.withTransaction(func(tx *sql.Tx) error {
-		if _, err := tx.ExecContext(context.Background(), query, ID); err != nil {
-			return fmt.Errorf("item_id \"%s\": %w", ID, err)
-		}
-		return nil
-	})
+	result, err := tx.ExecContext(ctx, query, ID)
+	if err != nil {
+		return 0, fmt.Errorf("item_id \"%s\": %w", ID, err)
+	}
+
+	deleted, err := result.RowsAffected()
+	return int(deleted), err
 }
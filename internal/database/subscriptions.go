@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"microsoft-apps-exporter/internal/models"
+	"time"
+)
+
+/*
+Subscriptions
+*/
+
+func (db *Database) GetSubscriptions(ctx context.Context) ([]models.GraphSubscription, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		id, resource, notification_url, lifecycle_notification_url, client_state,
+		expiration_date_time, created_at, last_notification_at
+	FROM graph_subscriptions;`
+
+	rows, err := db.Connection.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []models.GraphSubscription
+	for rows.Next() {
+		var subscription models.GraphSubscription
+		var lastNotificationAt sql.NullTime
+
+		err := rows.Scan(
+			&subscription.ID,
+			&subscription.Resource,
+			&subscription.NotificationURL,
+			&subscription.LifecycleNotificationURL,
+			&subscription.ClientState,
+			&subscription.ExpirationDateTime,
+			&subscription.CreatedAt,
+			&lastNotificationAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if lastNotificationAt.Valid {
+			subscription.LastNotificationAt = &lastNotificationAt.Time
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// SaveSubscription inserts the subscription or updates the stored one, keeping its creation and last notification time.
+func (db *Database) SaveSubscription(ctx context.Context, subscription models.GraphSubscription) error {
+	query := `
+		INSERT INTO graph_subscriptions (
+			id, resource, notification_url, lifecycle_notification_url, client_state, expiration_date_time
+		) VALUES (
+			$1, $2, $3, $4, $5, $6
+		)
+		ON CONFLICT (id) DO UPDATE SET
+			resource = EXCLUDED.resource,
+			notification_url = EXCLUDED.notification_url,
+			lifecycle_notification_url = EXCLUDED.lifecycle_notification_url,
+			client_state = EXCLUDED.client_state,
+			expiration_date_time = EXCLUDED.expiration_date_time;`
+
+	return db.withTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query,
+			subscription.ID,
+			subscription.Resource,
+			subscription.NotificationURL,
+			subscription.LifecycleNotificationURL,
+			subscription.ClientState,
+			subscription.ExpirationDateTime,
+		)
+		return err
+	})
+}
+
+func (db *Database) UpdateSubscriptionExpiry(ctx context.Context, ID string, expirationDateTime time.Time) error {
+	query := `
+		UPDATE graph_subscriptions
+		SET expiration_date_time = $2
+		WHERE id = $1;`
+
+	return db.withTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query, ID, expirationDateTime)
+		return err
+	})
+}
+
+func (db *Database) SaveSubscriptionNotification(ctx context.Context, ID string, notifiedAt time.Time) error {
+	query := `
+		UPDATE graph_subscriptions
+		SET last_notification_at = $2
+		WHERE id = $1;`
+
+	return db.withTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query, ID, notifiedAt)
+		return err
+	})
+}
+
+func (db *Database) DeleteSubscription(ctx context.Context, ID string) error {
+	query := `
+		DELETE FROM graph_subscriptions
+		WHERE id = $1;`
+
+	return db.withTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query, ID)
+		return err
+	})
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"microsoft-apps-exporter/internal/models"
+
+	"github.com/lib/pq"
+)
+
+/*
+Sync checkpoints
+*/
+
+// GetSyncCheckpoint returns the checkpoint of an interrupted sync of the list, or nil if there is none.
+func (db *Database) GetSyncCheckpoint(ctx context.Context, siteID, listID string) (*models.SyncCheckpoint, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		site_id, list_id, next_link, full_sync, pages, updated_at
+	FROM sync_checkpoints
+	WHERE site_id = $1 AND list_id = $2;`
+
+	var checkpoint models.SyncCheckpoint
+	err := db.Connection.QueryRowContext(ctx, query, siteID, listID).Scan(
+		&checkpoint.SiteID,
+		&checkpoint.ListID,
+		&checkpoint.NextLink,
+		&checkpoint.Full,
+		&checkpoint.Pages,
+		&checkpoint.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+// DeleteSyncCheckpoint discards the checkpoint of the list along with the items seen by the interrupted sync.
+func (db *Database) DeleteSyncCheckpoint(ctx context.Context, siteID, listID string) error {
+	return db.withTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if err := deleteSeenListItems(ctx, tx, siteID, listID); err != nil {
+			return err
+		}
+		return deleteSyncCheckpoint(ctx, tx, siteID, listID)
+	})
+}
+
+// saveSyncCheckpoint stores the position of the sync within the transaction applying its page.
+func saveSyncCheckpoint(ctx context.Context, tx *sql.Tx, checkpoint models.SyncCheckpoint) error {
+	query := `
+		INSERT INTO sync_checkpoints (
+			site_id, list_id, next_link, full_sync, pages
+		) VALUES (
+			$1, $2, $3, $4, $5
+		)
+		ON CONFLICT (site_id, list_id) DO UPDATE SET
+			next_link = EXCLUDED.next_link,
+			full_sync = EXCLUDED.full_sync,
+			pages = EXCLUDED.pages,
+			updated_at = NOW();`
+
+	_, err := tx.ExecContext(ctx, query,
+		checkpoint.SiteID, checkpoint.ListID, checkpoint.NextLink, checkpoint.Full, checkpoint.Pages)
+	return err
+}
+
+func deleteSyncCheckpoint(ctx context.Context, tx *sql.Tx, siteID, listID string) error {
+	query := `
+		DELETE FROM sync_checkpoints
+		WHERE site_id = $1 AND list_id = $2;`
+
+	_, err := tx.ExecContext(ctx, query, siteID, listID)
+	return err
+}
+
+// saveSeenListItems records the items of a page seen by a full sync, a replayed page is ignored.
+func saveSeenListItems(ctx context.Context, tx *sql.Tx, siteID, listID string, IDs []string) error {
+	if len(IDs) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO sync_seen_items (site_id, list_id, item_id)
+		SELECT $1, $2, UNNEST($3::text[])
+		ON CONFLICT DO NOTHING;`
+
+	_, err := tx.ExecContext(ctx, query, siteID, listID, pq.Array(IDs))
+	return err
+}
+
+func deleteSeenListItems(ctx context.Context, tx *sql.Tx, siteID, listID string) error {
+	query := `
+		DELETE FROM sync_seen_items
+		WHERE site_id = $1 AND list_id = $2;`
+
+	_, err := tx.ExecContext(ctx, query, siteID, listID)
+	return err
+}
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"microsoft-apps-exporter/internal/models"
+	"time"
+)
+
+/*
+Sync failures
+*/
+
+// SaveSyncFailure records a failed sync of the list and returns the number of failed attempts so far.
+// A failed full resync keeps the pending retry a full resync.
+func (db *Database) SaveSyncFailure(ctx context.Context, siteID, listID string, full bool, message string) (int, error) {
+	query := `
+		INSERT INTO sync_failures (
+			site_id, list_id, full_sync, error
+		) VALUES (
+			$1, $2, $3, $4
+		)
+		ON CONFLICT (site_id, list_id) DO UPDATE SET
+			full_sync = sync_failures.full_sync OR EXCLUDED.full_sync,
+			error = EXCLUDED.error,
+			attempts = sync_failures.attempts + 1,
+			last_failed_at = NOW()
+		RETURNING attempts;`
+
+	var attempts int
+	err := db.withTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return tx.QueryRowContext(ctx, query, siteID, listID, full, message).Scan(&attempts)
+	})
+	return attempts, err
+}
+
+// ScheduleSyncFailure sets the time of the next retry of the list, nil marks the failure as exhausted.
+func (db *Database) ScheduleSyncFailure(ctx context.Context, siteID, listID string, nextAttemptAt *time.Time) error {
+	query := `
+		UPDATE sync_failures
+		SET next_attempt_at = $3
+		WHERE site_id = $1 AND list_id = $2;`
+
+	return db.withTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query, siteID, listID, nextAttemptAt)
+		return err
+	})
+}
+
+// GetSyncFailure returns the failure of the list, or nil if its last sync did not fail.
+func (db *Database) GetSyncFailure(ctx context.Context, siteID, listID string) (*models.SyncFailure, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		site_id, list_id, full_sync, error, attempts, next_attempt_at, first_failed_at, last_failed_at
+	FROM sync_failures
+	WHERE site_id = $1 AND list_id = $2;`
+
+	var failure models.SyncFailure
+	var nextAttemptAt sql.NullTime
+
+	err := db.Connection.QueryRowContext(ctx, query, siteID, listID).Scan(
+		&failure.SiteID,
+		&failure.ListID,
+		&failure.Full,
+		&failure.Error,
+		&failure.Attempts,
+		&nextAttemptAt,
+		&failure.FirstFailedAt,
+		&failure.LastFailedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if nextAttemptAt.Valid {
+		failure.NextAttemptAt = &nextAttemptAt.Time
+	}
+	return &failure, nil
+}
+
+// GetDueSyncFailures returns the failures whose next retry is due at the given time.
+func (db *Database) GetDueSyncFailures(ctx context.Context, now time.Time) ([]models.SyncFailure, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		site_id, list_id, full_sync, error, attempts, next_attempt_at, first_failed_at, last_failed_at
+	FROM sync_failures
+	WHERE next_attempt_at IS NOT NULL AND next_attempt_at <= $1
+	ORDER BY next_attempt_at;`
+
+	rows, err := db.Connection.QueryContext(ctx, query, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var failures []models.SyncFailure
+	for rows.Next() {
+		var failure models.SyncFailure
+		var nextAttemptAt sql.NullTime
+
+		err := rows.Scan(
+			&failure.SiteID,
+			&failure.ListID,
+			&failure.Full,
+			&failure.Error,
+			&failure.Attempts,
+			&nextAttemptAt,
+			&failure.FirstFailedAt,
+			&failure.LastFailedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if nextAttemptAt.Valid {
+			failure.NextAttemptAt = &nextAttemptAt.Time
+		}
+		failures = append(failures, failure)
+	}
+
+	return failures, rows.Err()
+}
+
+func (db *Database) DeleteSyncFailure(ctx context.Context, siteID, listID string) error {
+	query := `
+		DELETE FROM sync_failures
+		WHERE site_id = $1 AND list_id = $2;`
+
+	return db.withTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query, siteID, listID)
+		return err
+	})
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"microsoft-apps-exporter/internal/models"
+)
+
+/*
+Pending syncs
+*/
+
+// SavePendingSyncs stores syncs left unfinished at shutdown. A pending full resync of a list
+// is kept a full resync when the list is saved again.
+func (db *Database) SavePendingSyncs(ctx context.Context, syncs []models.PendingSync) error {
+	query := `
+		INSERT INTO sync_pending (
+			site_id, list_id, full_sync, trigger
+		) VALUES (
+			$1, $2, $3, $4
+		)
+		ON CONFLICT (site_id, list_id) DO UPDATE SET
+			full_sync = sync_pending.full_sync OR EXCLUDED.full_sync;`
+
+	return db.withTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		for _, sync := range syncs {
+			if _, err := tx.ExecContext(ctx, query, sync.SiteID, sync.ListID, sync.Full, sync.Trigger); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetPendingSyncs returns the stored pending syncs, oldest first. They are kept until DeletePendingSync,
+// so the syncs resumed by a start that crashes are resumed again.
+func (db *Database) GetPendingSyncs(ctx context.Context) ([]models.PendingSync, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT site_id, list_id, full_sync, trigger, queued_at
+		FROM sync_pending
+		ORDER BY queued_at;`
+
+	rows, err := db.Connection.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var syncs []models.PendingSync
+	for rows.Next() {
+		var sync models.PendingSync
+		if err := rows.Scan(&sync.SiteID, &sync.ListID, &sync.Full, &sync.Trigger, &sync.QueuedAt); err != nil {
+			return nil, err
+		}
+		syncs = append(syncs, sync)
+	}
+	return syncs, rows.Err()
+}
+
+// DeletePendingSync removes the pending sync of the list once it has run.
+func (db *Database) DeletePendingSync(ctx context.Context, siteID, listID string) error {
+	return db.withTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM sync_pending WHERE site_id = $1 AND list_id = $2;`, siteID, listID)
+		return err
+	})
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"microsoft-apps-exporter/internal/models"
+)
+
+/*
+Sync runs
+*/
+
+func (db *Database) SaveSyncRun(ctx context.Context, run models.SyncRun) error {
+	query := `
+		INSERT INTO sync_runs (
+			site_id, list_id, trigger, full_sync, started_at, finished_at,
+			inserted, updated, deleted, pages, error
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+		);`
+
+	return db.withTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query,
+			run.SiteID,
+			run.ListID,
+			run.Trigger,
+			run.Full,
+			run.StartedAt,
+			run.FinishedAt,
+			run.Inserted,
+			run.Updated,
+			run.Deleted,
+			run.Pages,
+			run.Error,
+		)
+		return err
+	})
+}
+
+// GetSyncRuns returns the most recent sync runs of the list, newest first.
+func (db *Database) GetSyncRuns(ctx context.Context, siteID, listID string, limit int) ([]models.SyncRun, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		site_id, list_id, trigger, full_sync, started_at, finished_at,
+		inserted, updated, deleted, pages, error
+	FROM sync_runs
+	WHERE site_id = $1 AND list_id = $2
+	ORDER BY started_at DESC
+	LIMIT $3;`
+
+	rows, err := db.Connection.QueryContext(ctx, query, siteID, listID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []models.SyncRun
+	for rows.Next() {
+		var run models.SyncRun
+		var runError sql.NullString
+
+		err := rows.Scan(
+			&run.SiteID,
+			&run.ListID,
+			&run.Trigger,
+			&run.Full,
+			&run.StartedAt,
+			&run.FinishedAt,
+			&run.Inserted,
+			&run.Updated,
+			&run.Deleted,
+			&run.Pages,
+			&runError,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if runError.Valid {
+			run.Error = &runError.String
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
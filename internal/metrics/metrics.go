@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "microsoft_apps_exporter"
+
+// Registry holds every metric exposed by the exporter.
+var Registry = prometheus.NewRegistry()
+
+var (
+	SyncDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "sync_duration_seconds",
+		Help:      "Duration of SharePoint list syncs.",
+		Buckets:   []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1800},
+	}, []string{"site_id", "list_id", "outcome"})
+
+	SyncRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sync_runs_total",
+		Help:      "SharePoint list syncs by outcome.",
+	}, []string{"site_id", "list_id", "outcome"})
+
+	SyncRows = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sync_rows_total",
+		Help:      "List item rows changed by syncs.",
+	}, []string{"site_id", "list_id", "action"})
+
+	SchemaDrifts = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "schema_drifts",
+		Help:      "Mismatches between the SharePoint lists, their columns map and their tables found by the last sync.",
+	}, []string{"site_id", "list_id", "kind"})
+
+	GraphRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "graph_request_duration_seconds",
+		Help:      "Latency of Microsoft Graph API requests, including every retry attempt.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"graph_operation", "status_code"})
+
+	GraphRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "graph_requests_total",
+		Help:      "Microsoft Graph API requests by status code, including every retry attempt.",
+	}, []string{"graph_operation", "status_code"})
+
+	WebhookNotifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_notifications_received_total",
+		Help:      "Webhook notifications received.",
+	}, []string{"endpoint"})
+
+	WebhookNotificationsRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_notifications_rejected_total",
+		Help:      "Webhook notifications rejected.",
+	}, []string{"endpoint", "reason"})
+)
+
+// Outcomes and reasons used as label values.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+
+	ReasonMethodNotAllowed = "method_not_allowed"
+	ReasonInvalidPayload   = "invalid_payload"
+	ReasonClientState      = "client_state"
+	ReasonUnknownResource  = "unknown_resource"
+	ReasonHandlerError     = "handler_error"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		SyncDuration,
+		SyncRuns,
+		SyncRows,
+		SchemaDrifts,
+		GraphRequestDuration,
+		GraphRequests,
+		WebhookNotifications,
+		WebhookNotificationsRejected,
+	)
+}
+
+// Handler returns the HTTP handler exposing the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
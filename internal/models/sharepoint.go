@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"time"
 )
 
 const SharepointResourceSignature string = "sites/%s/lists/%s"
@@ -16,11 +17,51 @@ type SharepointResource struct {
 	Lists       []ListReference `mapstructure:"lists"`
 }
 
+// FindList returns the configured list reference for the given site and list IDs.
+func (r *SharepointResource) FindList(siteID, listID string) (ListReference, bool) {
+	if r == nil {
+		return ListReference{}, false
+	}
+
+	for _, list := range r.Lists {
+		if list.SiteID == siteID && list.ListID == listID {
+			return list, true
+		}
+	}
+	return ListReference{}, false
+}
+
 type ListReference struct {
 	SiteID      string            `mapstructure:"site_id"`
 	ListID      string            `mapstructure:"list_id"`
 	DbTableName string            `mapstructure:"database_table"`
 	ColumnsMap  map[string]string `mapstructure:"columns_map"`
+
+	// Transforms are the expressions computing the values of table columns, keyed by table column.
+	// A column of the columns map transforms its field, any other one is computed from the fields of the item.
+	Transforms map[string]string `mapstructure:"transforms"`
+
+	// Filter are the predicates on the fields an item must match to be exported, e.g. "Status in [Approved, Closed]".
+	Filter []string `mapstructure:"filter"`
+
+	// SyncInterval overrides the global scheduled sync interval for this list.
+	SyncInterval time.Duration `mapstructure:"sync_interval"`
+
+	// TimeZone is the IANA time zone of the site, e.g. "Europe/Paris", telling the calendar day of date only fields.
+	TimeZone string `mapstructure:"time_zone"`
+}
+
+// Location returns the time zone of the site, nil when none is configured.
+func (l ListReference) Location() (*time.Location, error) {
+	if l.TimeZone == "" {
+		return nil, nil
+	}
+
+	location, err := time.LoadLocation(l.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", l.TimeZone, err)
+	}
+	return location, nil
 }
 
 type ListMetadata struct {
@@ -46,6 +87,19 @@ type ListItemMetadata struct {
 
 type ListItemMappedFields map[string]any
 
+// ListItemChanges are the item changes of a list computed by a sync.
+type ListItemChanges struct {
+	Upsert []ListItem // Inserted, or updated when the item already exists
+	Delete []string   // IDs of the deleted items
+}
+
+// ChangeCounts are the numbers of rows affected by applied changes.
+type ChangeCounts struct {
+	Inserted int
+	Updated  int
+	Deleted  int
+}
+
 func (m *ListItemMetadata) AsArray() []interface{} {
 	return []interface{}{m.ID, m.ListID, m.SiteID, m.ETag}
 }
@@ -53,3 +107,31 @@ func (m *ListItemMetadata) AsArray() []interface{} {
 func (m ListItemMetadata) DbColumns() []string {
 	return []string{"id", "list_id", "site_id", "etag"}
 }
+
+// SharePoint column types, the calculated columns take the type of their output.
+const (
+	ColumnTypeText               = "text"
+	ColumnTypeNote               = "note" // Text over multiple lines
+	ColumnTypeNumber             = "number"
+	ColumnTypeCurrency           = "currency"
+	ColumnTypeBoolean            = "boolean"
+	ColumnTypeDateTime           = "dateTime"
+	ColumnTypeDate               = "date"
+	ColumnTypeChoice             = "choice"
+	ColumnTypeLookup             = "lookup"
+	ColumnTypePersonOrGroup      = "personOrGroup"
+	ColumnTypeHyperlinkOrPicture = "hyperlinkOrPicture"
+	ColumnTypeGeolocation        = "geolocation"
+	ColumnTypeTerm               = "term"
+	ColumnTypeThumbnail          = "thumbnail"
+)
+
+// ColumnDefinition describes a column of a SharePoint list.
+type ColumnDefinition struct {
+	Name        string // Internal name, the key of the column in the item fields
+	DisplayName string
+	Type        string // One of the ColumnType constants
+	MaxLength   int    // Maximum length of a text column, unbounded when zero
+	Multiple    bool   // The column holds several values
+	Required    bool
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// GraphSubscription is the persisted state of a Microsoft Graph API subscription managed by the exporter.
+type GraphSubscription struct {
+	ID                       string
+	Resource                 string
+	NotificationURL          string
+	LifecycleNotificationURL string
+	ClientState              string
+	ExpirationDateTime       time.Time
+	CreatedAt                time.Time
+	LastNotificationAt       *time.Time
+}
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// SyncFailure is a failed sync of a list waiting to be retried.
+type SyncFailure struct {
+	SiteID        string
+	ListID        string
+	Full          bool
+	Error         string
+	Attempts      int
+	NextAttemptAt *time.Time // Nil once the retry attempts are exhausted
+	FirstFailedAt time.Time
+	LastFailedAt  time.Time
+}
+
+// ClearedBy reports whether a successful sync clears the failure, a failed full resync is only cleared by a full sync.
+func (f SyncFailure) ClearedBy(full bool) bool {
+	return full || !f.Full
+}
+
+// SyncRun is the outcome of a single sync of a list.
+type SyncRun struct {
+	SiteID     string
+	ListID     string
+	Trigger    string
+	Full       bool // Synchronized without a delta link
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Inserted   int
+	Updated    int
+	Deleted    int
+	Pages      int     // Graph pages requested
+	Error      *string // Nil when the run succeeded
+}
+
+// PendingSync is a sync left unfinished at shutdown, resumed on the next start.
+type PendingSync struct {
+	SiteID   string
+	ListID   string
+	Full     bool
+	Trigger  string
+	QueuedAt time.Time
+}
+
+// SyncCheckpoint is the position of an interrupted list sync, stored after every applied page.
+type SyncCheckpoint struct {
+	SiteID    string
+	ListID    string
+	NextLink  string // Link of the first page not applied yet
+	Full      bool   // The interrupted sync enumerates the whole list
+	Pages     int    // Pages applied so far
+	UpdatedAt time.Time
+}
+
+// SyncProgress is the position of a list sync committed along with the changes of a batch of pages.
+type SyncProgress struct {
+	Full      bool     // Delete the items of the list not seen by the full sync on the last page
+	First     bool     // The changes are the first ones of a sync that was not resumed
+	Pages     int      // Pages applied, including the ones of the current batch
+	NextLink  *string  // Link of the following page, nil on the last page
+	DeltaLink *string  // Link of the next delta sync, stored on the last page
+	SeenIDs   []string // IDs of the items of the batch seen by the full sync
+}
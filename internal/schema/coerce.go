@@ -0,0 +1,226 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"microsoft-apps-exporter/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldCoercer converts the deserialized fields of list items to the values of their SharePoint column types.
+type FieldCoercer struct {
+	columns  map[string]models.ColumnDefinition // Keyed by internal name
+	location *time.Location                     // Time zone of the site, nil keeps the offset of the values
+}
+
+// NewFieldCoercer creates a coercer of the fields of a list from its column definitions
+// and the time zone of its site, which tells the calendar day of date only values.
+func NewFieldCoercer(columns []models.ColumnDefinition, location *time.Location) *FieldCoercer {
+	c := &FieldCoercer{columns: make(map[string]models.ColumnDefinition, len(columns)), location: location}
+	for _, column := range columns {
+		c.columns[column.Name] = column
+	}
+	return c
+}
+
+// Coerce converts the fields in place. A field that cannot be converted is set to nil,
+// the returned error joins the failures of every such field.
+// The fields without a column definition are kept, except objects and arrays, which are encoded as JSON.
+func (c *FieldCoercer) Coerce(fields models.ListItemMappedFields) error {
+	var errs []error
+	for name, value := range fields {
+		column, ok := c.columns[name]
+		if !ok {
+			column = models.ColumnDefinition{Name: name}
+		}
+
+		coerced, err := CoerceValue(column, value, c.location)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("field %s: %w", name, err))
+		}
+		fields[name] = coerced
+	}
+	return errors.Join(errs...)
+}
+
+// CoerceValue converts a value deserialized from JSON to the value of the column type:
+// dates and times become time.Time, date only values a YYYY-MM-DD string, numbers float64, booleans bool,
+// and the multiple values, hyperlinks, locations and terms a JSON string, stored either as JSONB or text.
+// Choices are text, lookups their looked up value and persons their email, or name without one.
+// A value of an unknown type is only encoded when it is an object or an array.
+// SharePoint stores a date only value as the midnight of the site time zone, its calendar day is told in the location,
+// or in the offset of the value when nil.
+func CoerceValue(column models.ColumnDefinition, value any, location *time.Location) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if column.Multiple {
+		if keys, ok := referenceKeys[column.Type]; ok {
+			return toReferencesJSON(value, keys)
+		}
+		return toJSON(value)
+	}
+
+	switch column.Type {
+	case models.ColumnTypeText, models.ColumnTypeNote, models.ColumnTypeChoice:
+		return toText(value)
+	case models.ColumnTypeLookup, models.ColumnTypePersonOrGroup:
+		return toReference(value, referenceKeys[column.Type])
+	case models.ColumnTypeNumber, models.ColumnTypeCurrency:
+		return toFloat(value)
+	case models.ColumnTypeBoolean:
+		return toBool(value)
+	case models.ColumnTypeDateTime:
+		return toTime(value)
+	case models.ColumnTypeDate:
+		date, err := toTime(value)
+		if err != nil || date == nil {
+			return nil, err
+		}
+		if location != nil {
+			return date.(time.Time).In(location).Format(time.DateOnly), nil
+		}
+		return date.(time.Time).Format(time.DateOnly), nil
+	case models.ColumnTypeHyperlinkOrPicture, models.ColumnTypeGeolocation, models.ColumnTypeTerm, models.ColumnTypeThumbnail:
+		return toJSON(value)
+	default:
+		switch value.(type) {
+		case map[string]any, []any:
+			return toJSON(value)
+		}
+		return value, nil
+	}
+}
+
+// referenceKeys are the keys of the lookup and person objects holding their value, by preference.
+var referenceKeys = map[string][]string{
+	models.ColumnTypeLookup:        {"LookupValue", "LookupId"},
+	models.ColumnTypePersonOrGroup: {"Email", "LookupValue", "DisplayName", "LookupId"},
+}
+
+// toReference extracts the value of a lookup or person object as text, the keys being matched case insensitively.
+// An object without any of the keys is encoded as JSON.
+func toReference(value any, keys []string) (any, error) {
+	object, ok := value.(map[string]any)
+	if !ok {
+		return toText(value)
+	}
+
+	for _, key := range keys {
+		for name, v := range object {
+			if strings.EqualFold(name, key) && v != nil && v != "" {
+				return toText(v)
+			}
+		}
+	}
+	return toJSON(value)
+}
+
+// toReferencesJSON extracts the values of several lookups or persons into a JSON array.
+func toReferencesJSON(value any, keys []string) (any, error) {
+	references, ok := value.([]any)
+	if !ok {
+		return toJSON(value)
+	}
+
+	values := make([]any, len(references))
+	for i, reference := range references {
+		if reference == nil {
+			continue
+		}
+		v, err := toReference(reference, keys)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return toJSON(values)
+}
+
+func toText(value any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case json.Number:
+		return v.String(), nil
+	default:
+		return toJSON(value)
+	}
+}
+
+func toFloat(value any) (any, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil, nil
+		}
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", v)
+		}
+		return number, nil
+	default:
+		return nil, fmt.Errorf("invalid number of type %T", value)
+	}
+}
+
+func toBool(value any) (any, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case float64:
+		return v != 0, nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil, nil
+		}
+		flag, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean %q", v)
+		}
+		return flag, nil
+	default:
+		return nil, fmt.Errorf("invalid boolean of type %T", value)
+	}
+}
+
+func toTime(value any) (any, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil, nil
+		}
+		parsed, err := time.Parse(time.RFC3339, strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid date and time %q", v)
+		}
+		return parsed, nil
+	default:
+		return nil, fmt.Errorf("invalid date and time of type %T", value)
+	}
+}
+
+func toJSON(value any) (any, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return string(encoded), nil
+}
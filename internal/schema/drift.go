@@ -0,0 +1,176 @@
+package schema
+
+import (
+	"fmt"
+	"microsoft-apps-exporter/internal/models"
+	"microsoft-apps-exporter/internal/transform"
+	"strings"
+)
+
+// DriftPolicy tells what a sync does when it detects a schema drift.
+type DriftPolicy string
+
+const (
+	DriftPolicyWarn    DriftPolicy = "warn"    // Log the drifts and sync anyway
+	DriftPolicyFail    DriftPolicy = "fail"    // Fail the sync
+	DriftPolicyMigrate DriftPolicy = "migrate" // Create the missing table and columns, warn about the other drifts
+)
+
+// ParseDriftPolicy parses a drift policy, case insensitively.
+func ParseDriftPolicy(value string) (DriftPolicy, error) {
+	switch policy := DriftPolicy(strings.ToLower(value)); policy {
+	case DriftPolicyWarn, DriftPolicyFail, DriftPolicyMigrate:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown schema drift policy %q", value)
+	}
+}
+
+// DriftKind is the kind of mismatch between a list, its columns map and its table.
+type DriftKind string
+
+const (
+	DriftMissingField  DriftKind = "missing_field"  // The mapped SharePoint column does not exist
+	DriftRenamedField  DriftKind = "renamed_field"  // The mapped name is only known as the display name of another column
+	DriftMissingTable  DriftKind = "missing_table"  // The list table does not exist
+	DriftMissingColumn DriftKind = "missing_column" // The list table lacks the mapped column
+	DriftTypeMismatch  DriftKind = "type_mismatch"  // The table column cannot store the values of the SharePoint column
+)
+
+// DriftKinds are every kind of drift.
+var DriftKinds = []DriftKind{DriftMissingField, DriftRenamedField, DriftMissingTable, DriftMissingColumn, DriftTypeMismatch}
+
+// Drift is a mismatch found by DetectDrift.
+type Drift struct {
+	Kind     DriftKind
+	DbColumn string // Mapped or computed table column, empty for a missing table
+	Field    string // Mapped SharePoint column, empty for a computed column
+	Expected string // Type of the SharePoint column, or the current name of a renamed field
+	Actual   string // Type of the table column
+}
+
+func (d Drift) String() string {
+	switch d.Kind {
+	case DriftMissingField:
+		return fmt.Sprintf("column %s is mapped to field %s, which does not exist in the list", d.DbColumn, d.Field)
+	case DriftRenamedField:
+		return fmt.Sprintf("column %s is mapped to field %s, which is now named %s", d.DbColumn, d.Field, d.Expected)
+	case DriftMissingTable:
+		return "table does not exist"
+	case DriftMissingColumn:
+		if d.Field == "" {
+			return fmt.Sprintf("column %s computed by its transform does not exist, expected %s", d.DbColumn, d.Expected)
+		}
+		return fmt.Sprintf("column %s mapped to field %s does not exist, expected %s", d.DbColumn, d.Field, d.Expected)
+	case DriftTypeMismatch:
+		return fmt.Sprintf("column %s is %s, field %s requires %s", d.DbColumn, d.Actual, d.Field, d.Expected)
+	default:
+		return string(d.Kind)
+	}
+}
+
+// Fixable reports whether the drift is fixed by the migrate policy.
+func (d Drift) Fixable() bool {
+	return d.Kind == DriftMissingTable || d.Kind == DriftMissingColumn
+}
+
+// DetectDrift compares the columns written by the pipeline of the list, mapped or computed by a transform,
+// with the current SharePoint columns and the columns of its table, keyed by name with their information_schema
+// data type. An empty table columns map stands for a missing table. The drifts are sorted by table column.
+func DetectDrift(list models.ListReference, transforms *transform.Pipeline, columns []models.ColumnDefinition,
+	tableColumns map[string]string) []Drift {
+	byName := make(map[string]models.ColumnDefinition, len(columns))
+	byDisplayName := make(map[string]models.ColumnDefinition, len(columns))
+	for _, column := range columns {
+		byName[column.Name] = column
+		byDisplayName[strings.ToLower(column.DisplayName)] = column
+	}
+
+	var drifts []Drift
+	if len(tableColumns) == 0 {
+		drifts = append(drifts, Drift{Kind: DriftMissingTable})
+	}
+
+	for _, dbColumn := range sortedColumns(transforms) {
+		field, mapped := list.ColumnsMap[dbColumn]
+		if !mapped {
+			// A computed column only has to exist, its type is told by its transform
+			if _, ok := tableColumns[dbColumn]; !ok && len(tableColumns) > 0 {
+				drifts = append(drifts, Drift{Kind: DriftMissingColumn, DbColumn: dbColumn, Expected: transforms.Type(dbColumn, "")})
+			}
+			continue
+		}
+
+		column, ok := byName[field]
+		if !ok {
+			if renamed, ok := byDisplayName[strings.ToLower(field)]; ok {
+				drifts = append(drifts, Drift{Kind: DriftRenamedField, DbColumn: dbColumn, Field: field, Expected: renamed.Name})
+			} else {
+				drifts = append(drifts, Drift{Kind: DriftMissingField, DbColumn: dbColumn, Field: field})
+			}
+			continue
+		}
+		if len(tableColumns) == 0 {
+			continue
+		}
+
+		// The type of a transformed column is told by its transform rather than its field
+		_, transformed := list.Transforms[dbColumn]
+		expected := transforms.Type(dbColumn, PostgresType(column))
+		actual, ok := tableColumns[dbColumn]
+		switch {
+		case !ok:
+			drifts = append(drifts, Drift{Kind: DriftMissingColumn, DbColumn: dbColumn, Field: field, Expected: expected})
+		case !transformed && !compatibleTypes(expected, actual):
+			drifts = append(drifts, Drift{Kind: DriftTypeMismatch, DbColumn: dbColumn, Field: field, Expected: expected, Actual: actual})
+		}
+	}
+
+	return drifts
+}
+
+// AddColumnStatement returns the DDL adding the column to the table unless it exists.
+func AddColumnStatement(table string, column Column) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s;", table, column.Name, column.Type)
+}
+
+// compatibleTypes reports whether a column of the actual type stores the values of the expected type.
+// Text columns store every scalar value and timestamps store dates.
+func compatibleTypes(expected, actual string) bool {
+	expectedFamily, actualFamily := typeFamily(expected), typeFamily(actual)
+	switch {
+	case expectedFamily == actualFamily:
+		return true
+	case actualFamily == "text":
+		return expectedFamily != "json"
+	case actualFamily == "timestamp":
+		return expectedFamily == "date"
+	default:
+		return false
+	}
+}
+
+// typeFamily groups the PostgreSQL types, either as written in DDL or as reported by information_schema.
+func typeFamily(pgType string) string {
+	pgType = strings.ToLower(pgType)
+	if i := strings.IndexByte(pgType, '('); i >= 0 {
+		pgType = strings.TrimSpace(pgType[:i])
+	}
+
+	switch pgType {
+	case "text", "varchar", "character varying", "char", "character", "bpchar":
+		return "text"
+	case "double precision", "real", "numeric", "decimal", "integer", "bigint", "smallint", "float4", "float8", "int2", "int4", "int8":
+		return "number"
+	case "boolean", "bool":
+		return "boolean"
+	case "date":
+		return "date"
+	case "json", "jsonb":
+		return "json"
+	}
+	if strings.HasPrefix(pgType, "timestamp") {
+		return "timestamp"
+	}
+	return pgType
+}
@@ -0,0 +1,154 @@
+package schema
+
+import (
+	"fmt"
+	"microsoft-apps-exporter/internal/models"
+	"microsoft-apps-exporter/internal/transform"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Column is a column of a list items table.
+type Column struct {
+	Name       string
+	Type       string // PostgreSQL type
+	Constraint string // Column constraint, e.g. NOT NULL
+	Source     string // Name of the SharePoint column, empty for the metadata columns
+}
+
+// Table is the table storing the items of a SharePoint list.
+type Table struct {
+	Name    string
+	Columns []Column
+}
+
+// metadataColumns are the leading columns of every list items table, see models.ListItemMetadata.
+var metadataColumns = []Column{
+	{Name: "id", Type: "VARCHAR(40)", Constraint: "NOT NULL PRIMARY KEY"},
+	{Name: "list_id", Type: "VARCHAR(40)", Constraint: "NOT NULL"},
+	{Name: "site_id", Type: "VARCHAR(100)", Constraint: "NOT NULL"},
+	{Name: "etag", Type: "VARCHAR(45)", Constraint: "NOT NULL"},
+}
+
+// PostgresType maps the type of a SharePoint column to a PostgreSQL type.
+// The values SharePoint returns as objects or arrays are stored as JSONB.
+func PostgresType(column models.ColumnDefinition) string {
+	if column.Multiple {
+		return "JSONB"
+	}
+
+	switch column.Type {
+	case models.ColumnTypeText:
+		if column.MaxLength > 0 {
+			return fmt.Sprintf("VARCHAR(%d)", column.MaxLength)
+		}
+		return "TEXT"
+	case models.ColumnTypeNumber:
+		return "DOUBLE PRECISION"
+	case models.ColumnTypeCurrency:
+		return "NUMERIC(19, 4)"
+	case models.ColumnTypeBoolean:
+		return "BOOLEAN"
+	case models.ColumnTypeDateTime:
+		return "TIMESTAMPTZ"
+	case models.ColumnTypeDate:
+		return "DATE"
+	case models.ColumnTypeHyperlinkOrPicture, models.ColumnTypeGeolocation, models.ColumnTypeTerm, models.ColumnTypeThumbnail:
+		return "JSONB"
+	default:
+		return "TEXT"
+	}
+}
+
+// BuildTable builds the table of a list from its column definitions and the transforms of its pipeline.
+// The mapped and computed columns are sorted by name, every column of the columns map must be defined by the list.
+// A transformed column has the type of the result of its transform.
+func BuildTable(list models.ListReference, transforms *transform.Pipeline, columns []models.ColumnDefinition) (Table, error) {
+	if list.DbTableName == "" {
+		return Table{}, fmt.Errorf("no database table configured for list %s", list.ListID)
+	}
+
+	definitions := make(map[string]models.ColumnDefinition, len(columns))
+	for _, column := range columns {
+		definitions[column.Name] = column
+	}
+
+	table := Table{Name: list.DbTableName, Columns: append([]Column{}, metadataColumns...)}
+	var missing []string
+	for _, dbColumn := range sortedColumns(transforms) {
+		source, mapped := list.ColumnsMap[dbColumn]
+		if !mapped {
+			table.Columns = append(table.Columns, Column{Name: dbColumn, Type: transforms.Type(dbColumn, "")})
+			continue
+		}
+
+		definition, ok := definitions[source]
+		if !ok {
+			missing = append(missing, source)
+			continue
+		}
+		table.Columns = append(table.Columns, Column{Name: dbColumn, Type: transforms.Type(dbColumn, PostgresType(definition)),
+			Source: source})
+	}
+	if len(missing) > 0 {
+		return Table{}, fmt.Errorf("columns not found in list %s: %s", list.ListID, strings.Join(missing, ", "))
+	}
+
+	return table, nil
+}
+
+// sortedColumns returns the table columns written by the pipeline, sorted by name.
+func sortedColumns(transforms *transform.Pipeline) []string {
+	columns := transforms.Columns()
+	dbColumns := make([]string, 0, len(columns))
+	for dbColumn := range columns {
+		dbColumns = append(dbColumns, dbColumn)
+	}
+	sort.Strings(dbColumns)
+	return dbColumns
+}
+
+// CreateStatement returns the DDL creating the table unless it exists.
+// The items are removed along with their list.
+func (t Table) CreateStatement() string {
+	// Align the names and the types as the hand-written migrations do
+	nameWidth, typeWidth := 0, 0
+	for _, column := range t.Columns {
+		nameWidth = max(nameWidth, len(column.Name))
+		typeWidth = max(typeWidth, len(column.Type))
+	}
+
+	lines := make([]string, 0, len(t.Columns)+1)
+	for _, column := range t.Columns {
+		line := fmt.Sprintf("    %-*s %-*s %s", nameWidth, column.Name, typeWidth, column.Type, column.Constraint)
+		lines = append(lines, strings.TrimRight(line, " "))
+	}
+	lines = append(lines, "    FOREIGN KEY (list_id) REFERENCES sharepoint_lists(id) ON DELETE CASCADE")
+
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n%s\n);", t.Name, strings.Join(lines, ",\n"))
+}
+
+// DropStatement returns the DDL dropping the table.
+func (t Table) DropStatement() string {
+	return fmt.Sprintf("DROP TABLE %s;", t.Name)
+}
+
+// Migration returns the goose migration creating the table.
+func (t Table) Migration() string {
+	return fmt.Sprintf(`-- +goose Up
+-- +goose StatementBegin
+%s
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+%s
+-- +goose StatementEnd
+`, t.CreateStatement(), t.DropStatement())
+}
+
+// MigrationFileName returns the name of the migration creating the table, following the goose naming.
+func (t Table) MigrationFileName(now time.Time) string {
+	return fmt.Sprintf("%s_create_%s.sql", now.UTC().Format("20060102150405"), t.Name)
+}
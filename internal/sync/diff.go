@@ -32,26 +32,17 @@ func diffFull[T any](existing, incoming []T, getID, getETag func(T) string) (toI
 	return
 }
 
-// diffDelta compares two datasets (existing and changes) and determines which records should be inserted, updated, or deleted in a delta sync.
-// - existing: A slice of existing records, each with an ID and ETag.
+// diffDelta splits the changes of a delta sync into records to upsert and records to delete,
+// without comparing them to the existing ones.
 // - changes: A slice of changed records, each with an ID and ETag.
 // - getID: A function that takes an object and returns its ID as a string.
-// - getETag: A function that takes an object and returns its ETag as a string.
-func diffDelta[T any](existing, changes []T, getID, getETag func(T) string) (toInsert, toUpdate []T, toDelete []string) {
-	existingMap := make(map[string]string, len(existing))
-	for _, e := range existing {
-		existingMap[getID(e)] = getETag(e)
-	}
-
+// - getETag: A function that takes an object and returns its ETag as a string, empty for a deleted record.
+func diffDelta[T any](changes []T, getID, getETag func(T) string) (toUpsert []T, toDelete []string) {
 	for _, change := range changes {
-		id, eTag := getID(change), getETag(change)
-
-		if eTag == "" {
-			toDelete = append(toDelete, id) // Deletion
-		} else if _, found := existingMap[id]; found {
-			toUpdate = append(toUpdate, change) // Update if changed
+		if getETag(change) == "" {
+			toDelete = append(toDelete, getID(change)) // Deletion
 		} else {
-			toInsert = append(toInsert, change) // Insert new record
+			toUpsert = append(toUpsert, change) // Inserted or updated record
 		}
 	}
 
@@ -0,0 +1,108 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"microsoft-apps-exporter/internal/metrics"
+	"microsoft-apps-exporter/internal/models"
+	"microsoft-apps-exporter/internal/schema"
+	"microsoft-apps-exporter/internal/tracing"
+	"microsoft-apps-exporter/internal/transform"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// checkSchema compares the columns written by the pipeline of the list with its SharePoint columns and its table,
+// then applies the drift policy: the drifts are logged, fail the sync, or the missing table and columns are created.
+// It costs a Graph request and a catalog query per sync, the column definitions are returned for the field coercion.
+func (s *Syncer) checkSchema(ctx context.Context, list models.ListReference,
+	transforms *transform.Pipeline) (columns []models.ColumnDefinition, err error) {
+	ctx, span := tracing.Start(ctx, "sync.schema", append(listAttributes(list),
+		attribute.String("policy", string(s.DriftPolicy)))...)
+	defer func() { tracing.End(span, err) }()
+
+	graphCtx, graphSpan := tracing.Start(ctx, "graph.GetListColumns", listAttributes(list)...)
+	columns, err = s.Graph.GetListColumns(graphCtx, list.SiteID, list.ListID)
+	tracing.End(graphSpan, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve list columns from API: %w", err)
+	}
+
+	tableColumns, err := s.Database.GetTableColumns(ctx, list.DbTableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve table columns from database: %w", err)
+	}
+
+	drifts := schema.DetectDrift(list, transforms, columns, tableColumns)
+	if s.DriftPolicy == schema.DriftPolicyMigrate {
+		drifts, err = s.migrateSchema(ctx, list, transforms, columns, drifts)
+		if err != nil {
+			return nil, err
+		}
+	}
+	recordDrifts(list, drifts)
+	span.SetAttributes(attribute.Int("drifts", len(drifts)))
+	if len(drifts) == 0 {
+		return columns, nil
+	}
+
+	if s.DriftPolicy == schema.DriftPolicyFail {
+		messages := make([]string, len(drifts))
+		for i, drift := range drifts {
+			messages[i] = drift.String()
+		}
+		return nil, fmt.Errorf("schema drift of table %s: %s", list.DbTableName, strings.Join(messages, "; "))
+	}
+
+	for _, drift := range drifts {
+		slog.Warn("Schema drift detected", "site_id", list.SiteID, "list_id", list.ListID,
+			"database_table", list.DbTableName, "kind", drift.Kind, "drift", drift.String(), "operation", "sync")
+	}
+	return columns, nil
+}
+
+// migrateSchema creates the missing table or columns and returns the drifts left.
+// A missing table is only created once every mapped field exists.
+func (s *Syncer) migrateSchema(ctx context.Context, list models.ListReference, transforms *transform.Pipeline,
+	columns []models.ColumnDefinition, drifts []schema.Drift) ([]schema.Drift, error) {
+	remaining := make([]schema.Drift, 0, len(drifts))
+	for _, drift := range drifts {
+		if !drift.Fixable() {
+			remaining = append(remaining, drift)
+			continue
+		}
+
+		var statement string
+		if drift.Kind == schema.DriftMissingTable {
+			table, err := schema.BuildTable(list, transforms, columns)
+			if err != nil {
+				// The missing fields are reported as drifts of their own
+				remaining = append(remaining, drift)
+				continue
+			}
+			statement = table.CreateStatement()
+		} else {
+			statement = schema.AddColumnStatement(list.DbTableName, schema.Column{Name: drift.DbColumn, Type: drift.Expected})
+		}
+
+		if err := s.Database.ApplySchema(ctx, statement); err != nil {
+			return nil, fmt.Errorf("failed to migrate table %s: %w", list.DbTableName, err)
+		}
+		slog.Info("Schema drift migrated", "site_id", list.SiteID, "list_id", list.ListID,
+			"database_table", list.DbTableName, "kind", drift.Kind, "drift", drift.String(), "operation", "sync")
+	}
+	return remaining, nil
+}
+
+// recordDrifts exposes the number of drifts of the list by kind.
+func recordDrifts(list models.ListReference, drifts []schema.Drift) {
+	counts := make(map[schema.DriftKind]int, len(schema.DriftKinds))
+	for _, drift := range drifts {
+		counts[drift.Kind]++
+	}
+	for _, kind := range schema.DriftKinds {
+		metrics.SchemaDrifts.WithLabelValues(list.SiteID, list.ListID, string(kind)).Set(float64(counts[kind]))
+	}
+}
@@ -0,0 +1,240 @@
+package sync
+
+import (
+	"context"
+	"log/slog"
+	"microsoft-apps-exporter/internal/models"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SyncTrigger tells what requested a sync.
+type SyncTrigger string
+
+const (
+	TriggerStartup  SyncTrigger = "startup"
+	TriggerWebhook  SyncTrigger = "webhook"
+	TriggerSchedule SyncTrigger = "schedule"
+	TriggerRetry    SyncTrigger = "retry"
+	TriggerManual   SyncTrigger = "manual"
+)
+
+// SyncJob describes a requested sync of a list.
+type SyncJob struct {
+	List    models.ListReference
+	Full    bool        // Discard the delta link and synchronize the list from scratch
+	Trigger SyncTrigger // Coalesced requests keep the trigger of the pending one
+	Pending bool        // Stored at the previous shutdown, the stored sync is removed once the job has run
+
+	SpanContext trace.SpanContext // Span of the request, the sync span is started as its child
+}
+
+// SyncQueue coalesces sync requests per list, runs at most one sync per list at a time
+// and bounds the number of lists synchronized concurrently.
+type SyncQueue struct {
+	syncFn         func(context.Context, SyncJob) error
+	maxConcurrency int
+
+	mu       sync.Mutex
+	pending  map[string]SyncJob  // Requests waiting to run, keyed by list resource
+	order    []string            // FIFO order of pending keys
+	inFlight map[string]struct{} // Lists currently being synchronized
+	notify   chan struct{}
+	closed   bool
+	closing  chan struct{} // Closed by Close, stops the idle workers
+	stopped  chan struct{} // Closed once Run returns
+}
+
+// NewSyncQueue creates a new SyncQueue executing syncFn with up to maxConcurrency workers.
+// The syncs receive the context of Run, so they are cancelled along with the queue.
+func NewSyncQueue(syncFn func(context.Context, SyncJob) error, maxConcurrency int) *SyncQueue {
+	if maxConcurrency < 1 {
+		maxConcurrency = 1
+	}
+
+	return &SyncQueue{
+		syncFn:         syncFn,
+		maxConcurrency: maxConcurrency,
+		pending:        make(map[string]SyncJob),
+		inFlight:       make(map[string]struct{}),
+		notify:         make(chan struct{}, 1),
+		closing:        make(chan struct{}),
+		stopped:        make(chan struct{}),
+	}
+}
+
+// Enqueue requests a sync of the list. A request for a list that is already pending is coalesced
+// into the pending one, a request for a list that is in flight runs once the current sync finishes.
+// It reports whether a new request was queued.
+func (q *SyncQueue) Enqueue(job SyncJob) bool {
+	return q.enqueue(job, false)
+}
+
+// EnqueueIdle requests a sync of the list only if it is neither pending nor in flight.
+// It reports whether a new request was queued.
+func (q *SyncQueue) EnqueueIdle(job SyncJob) bool {
+	return q.enqueue(job, true)
+}
+
+// Run starts the workers and blocks until the context is cancelled or the queue is closed,
+// and running syncs return.
+func (q *SyncQueue) Run(ctx context.Context) {
+	defer close(q.stopped)
+	slog.Info("Sync queue started", "max_concurrency", q.maxConcurrency, "operation", "queue")
+
+	var wg sync.WaitGroup
+	for range q.maxConcurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.worker(ctx)
+		}()
+	}
+
+	wg.Wait()
+	slog.Info("Sync queue stopped", "operation", "queue")
+}
+
+// Close stops the queue from accepting and starting syncs, the running ones are left to finish.
+// It returns the pending requests which will not run, oldest first.
+func (q *SyncQueue) Close() []SyncJob {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	close(q.closing)
+
+	jobs := make([]SyncJob, 0, len(q.order))
+	for _, key := range q.order {
+		jobs = append(jobs, q.pending[key])
+	}
+	q.pending = make(map[string]SyncJob)
+	q.order = nil
+	return jobs
+}
+
+// Closed reports whether the queue no longer accepts requests.
+func (q *SyncQueue) Closed() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.closed
+}
+
+// Stopped returns a channel closed once Run has returned.
+func (q *SyncQueue) Stopped() <-chan struct{} {
+	return q.stopped
+}
+
+// Len returns the number of pending requests.
+func (q *SyncQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+func (q *SyncQueue) enqueue(job SyncJob, onlyIfIdle bool) bool {
+	key := listKey(job.List)
+
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		slog.Warn("Sync request rejected, queue is closed", "site_id", job.List.SiteID, "list_id", job.List.ListID,
+			"operation", "queue")
+		return false
+	}
+
+	pending, isPending := q.pending[key]
+	_, isInFlight := q.inFlight[key]
+	if isPending || (onlyIfIdle && isInFlight) {
+		if isPending {
+			pending.Full = pending.Full || job.Full // Full resync supersedes the pending delta sync
+			pending.Pending = pending.Pending || job.Pending
+			q.pending[key] = pending
+		}
+		q.mu.Unlock()
+		slog.Debug("Sync request coalesced", "site_id", job.List.SiteID, "list_id", job.List.ListID,
+			"pending", isPending, "in_flight", isInFlight, "operation", "queue")
+		return false
+	}
+
+	q.pending[key] = job
+	q.order = append(q.order, key)
+	q.mu.Unlock()
+
+	q.wake()
+	return true
+}
+
+// worker runs queued syncs until the context is cancelled or the queue is closed.
+func (q *SyncQueue) worker(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, ok := q.take()
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.closing:
+				return
+			case <-q.notify:
+				continue
+			}
+		}
+
+		q.wake() // Let another worker pick up the remaining requests
+		q.run(ctx, job)
+	}
+}
+
+// take pops the oldest pending request whose list is not in flight.
+func (q *SyncQueue) take() (SyncJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return SyncJob{}, false
+	}
+	for i, key := range q.order {
+		if _, busy := q.inFlight[key]; busy {
+			continue
+		}
+
+		job := q.pending[key]
+		delete(q.pending, key)
+		q.order = append(q.order[:i], q.order[i+1:]...)
+		q.inFlight[key] = struct{}{}
+		return job, true
+	}
+	return SyncJob{}, false
+}
+
+// run executes the sync and releases the list afterwards.
+func (q *SyncQueue) run(ctx context.Context, job SyncJob) {
+	defer func() {
+		q.mu.Lock()
+		delete(q.inFlight, listKey(job.List))
+		q.mu.Unlock()
+		q.wake() // A follow-up request for the list may be waiting
+	}()
+
+	if err := q.syncFn(ctx, job); err != nil {
+		slog.Error("Failed to sync SharePoint resource", "site_id", job.List.SiteID, "list_id", job.List.ListID,
+			"full", job.Full, "exception", err, "operation", "queue")
+	}
+}
+
+// wake signals an idle worker without blocking.
+func (q *SyncQueue) wake() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// listKey identifies a list in the queue.
+func listKey(list models.ListReference) string {
+	return models.GenerateSharepointResourceString(list.SiteID, list.ListID)
+}
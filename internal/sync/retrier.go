@@ -0,0 +1,165 @@
+package sync
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"microsoft-apps-exporter/internal/configuration"
+	"time"
+)
+
+// Retrier records failed sync jobs in the sync_failures table and replays them with exponential backoff.
+// Failures that exhaust their attempts are kept in the table without a next attempt.
+type Retrier struct {
+	Syncer      *Syncer
+	Interval    time.Duration // How often due failures are looked up
+	BaseDelay   time.Duration // Delay before the first retry, doubled on every further attempt
+	MaxDelay    time.Duration // Upper bound of the delay between retries
+	MaxAttempts int           // Number of failed attempts after which the list is no longer retried
+}
+
+// NewRetrier creates a new Retrier using the retry policy from configuration.
+func NewRetrier(syncer *Syncer) *Retrier {
+	config := configuration.GetConfig()
+	return &Retrier{
+		Syncer:      syncer,
+		Interval:    config.SYNC_RETRY_INTERVAL,
+		BaseDelay:   config.SYNC_RETRY_BASE_DELAY,
+		MaxDelay:    config.SYNC_RETRY_MAX_DELAY,
+		MaxAttempts: config.SYNC_RETRY_MAX_ATTEMPTS,
+	}
+}
+
+// Run replays due failures on every interval until the context is cancelled.
+func (r *Retrier) Run(ctx context.Context) {
+	if r.Syncer.Database == nil || r.Interval <= 0 {
+		slog.Info("Sync retries disabled", "operation", "retry")
+		return
+	}
+
+	slog.Info("Sync retrier started", "interval", r.Interval, "max_attempts", r.MaxAttempts, "operation", "retry")
+
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Sync retrier stopped", "operation", "retry")
+			return
+		case <-ticker.C:
+			r.retryDue(ctx)
+		}
+	}
+}
+
+// track records the outcome of a sync job, clearing the failure of the list once a sync covering it succeeds.
+func (r *Retrier) track(ctx context.Context, job SyncJob, syncErr error) {
+	if r.Syncer.Database == nil {
+		return
+	}
+	list := job.List
+
+	if syncErr == nil {
+		r.clear(ctx, job)
+		return
+	}
+
+	attempts, err := r.Syncer.Database.SaveSyncFailure(ctx, list.SiteID, list.ListID, job.Full, syncErr.Error())
+	if err != nil {
+		slog.Error("Failed to record sync failure", "site_id", list.SiteID, "list_id", list.ListID,
+			"exception", err, "operation", "retry")
+		return
+	}
+
+	nextAttemptAt := r.nextAttempt(attempts, time.Now())
+	if err := r.Syncer.Database.ScheduleSyncFailure(ctx, list.SiteID, list.ListID, nextAttemptAt); err != nil {
+		slog.Error("Failed to schedule sync retry", "site_id", list.SiteID, "list_id", list.ListID,
+			"exception", err, "operation", "retry")
+		return
+	}
+
+	if nextAttemptAt == nil {
+		slog.Error("Sync retry attempts exhausted", "site_id", list.SiteID, "list_id", list.ListID,
+			"attempts", attempts, "operation", "retry")
+		return
+	}
+	slog.Info("Sync retry scheduled", "site_id", list.SiteID, "list_id", list.ListID,
+		"attempts", attempts, "next_attempt_at", *nextAttemptAt, "operation", "retry")
+}
+
+// clear deletes the failure of the list after a successful sync, unless the job does not cover it.
+// A failed full resync stays scheduled after a delta sync, as the delta link does not restore the missed changes.
+func (r *Retrier) clear(ctx context.Context, job SyncJob) {
+	list := job.List
+
+	failure, err := r.Syncer.Database.GetSyncFailure(ctx, list.SiteID, list.ListID)
+	if err != nil {
+		slog.Error("Failed to retrieve sync failure", "site_id", list.SiteID, "list_id", list.ListID,
+			"exception", err, "operation", "retry")
+		return
+	}
+	if failure == nil {
+		return
+	}
+	if !failure.ClearedBy(job.Full) {
+		slog.Info("Keeping failed full resync after a delta sync", "site_id", list.SiteID, "list_id", list.ListID,
+			"attempts", failure.Attempts, "operation", "retry")
+		return
+	}
+
+	if err := r.Syncer.Database.DeleteSyncFailure(ctx, list.SiteID, list.ListID); err != nil {
+		slog.Error("Failed to clear sync failure", "site_id", list.SiteID, "list_id", list.ListID,
+			"exception", err, "operation", "retry")
+	}
+}
+
+// retryDue queues the failures whose next attempt is due.
+func (r *Retrier) retryDue(ctx context.Context) {
+	failures, err := r.Syncer.Database.GetDueSyncFailures(ctx, time.Now())
+	if err != nil {
+		slog.Error("Failed to retrieve due sync failures", "exception", err, "operation", "retry")
+		return
+	}
+
+	config := configuration.GetConfig()
+	for _, failure := range failures {
+		list, found := config.Sharepoint.FindList(failure.SiteID, failure.ListID)
+		if !found {
+			slog.Info("Discarding sync failure of list no longer configured", "site_id", failure.SiteID,
+				"list_id", failure.ListID, "operation", "retry")
+			if err := r.Syncer.Database.DeleteSyncFailure(ctx, failure.SiteID, failure.ListID); err != nil {
+				slog.Error("Failed to discard sync failure", "site_id", failure.SiteID, "list_id", failure.ListID,
+					"exception", err, "operation", "retry")
+			}
+			continue
+		}
+
+		if r.Syncer.Queue.EnqueueIdle(SyncJob{List: list, Full: failure.Full, Trigger: TriggerRetry}) {
+			slog.Info("Retrying failed sync", "site_id", list.SiteID, "list_id", list.ListID,
+				"attempts", failure.Attempts, "full", failure.Full, "operation", "retry")
+		}
+	}
+}
+
+// nextAttempt returns the time of the retry following the given number of failed attempts,
+// or nil if the attempts are exhausted.
+func (r *Retrier) nextAttempt(attempts int, now time.Time) *time.Time {
+	if attempts >= r.MaxAttempts {
+		return nil
+	}
+
+	backoff := r.BaseDelay << min(attempts-1, 30)
+	if backoff <= 0 || backoff > r.MaxDelay { // Overflow or cap
+		backoff = r.MaxDelay
+	}
+
+	// Keep half of the backoff and randomize the rest to spread retries of lists failing together
+	half := backoff / 2
+	if half > 0 {
+		backoff = half + rand.N(backoff-half+1)
+	}
+
+	next := now.Add(backoff)
+	return &next
+}
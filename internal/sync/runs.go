@@ -0,0 +1,63 @@
+package sync
+
+import (
+	"context"
+	"log/slog"
+	"microsoft-apps-exporter/internal/metrics"
+	"microsoft-apps-exporter/internal/models"
+	"time"
+)
+
+// newSyncRun starts the record of a sync job.
+func newSyncRun(job SyncJob) *models.SyncRun {
+	trigger := job.Trigger
+	if trigger == "" {
+		trigger = TriggerManual
+	}
+
+	return &models.SyncRun{
+		SiteID:    job.List.SiteID,
+		ListID:    job.List.ListID,
+		Trigger:   string(trigger),
+		Full:      job.Full,
+		StartedAt: time.Now(),
+	}
+}
+
+// recordRun completes the sync run with its outcome, records its metrics and persists it to the sync_runs table.
+func (s *Syncer) recordRun(ctx context.Context, run *models.SyncRun, syncErr error) {
+	run.FinishedAt = time.Now()
+	if syncErr != nil {
+		message := syncErr.Error()
+		run.Error = &message
+	}
+
+	slog.Info("Sync run finished", "site_id", run.SiteID, "list_id", run.ListID, "trigger", run.Trigger,
+		"full", run.Full, "duration", run.FinishedAt.Sub(run.StartedAt).String(), "pages", run.Pages,
+		slog.Group("changes", "inserted", run.Inserted, "updated", run.Updated, "deleted", run.Deleted),
+		"failed", syncErr != nil, "operation", "sync")
+
+	observeRun(run, syncErr)
+
+	if s.Database == nil {
+		return
+	}
+	if err := s.Database.SaveSyncRun(ctx, *run); err != nil {
+		slog.Error("Failed to record sync run", "site_id", run.SiteID, "list_id", run.ListID,
+			"exception", err, "operation", "sync")
+	}
+}
+
+// observeRun records the duration, outcome and row changes of a finished sync run.
+func observeRun(run *models.SyncRun, syncErr error) {
+	outcome := metrics.OutcomeSuccess
+	if syncErr != nil {
+		outcome = metrics.OutcomeFailure
+	}
+
+	metrics.SyncDuration.WithLabelValues(run.SiteID, run.ListID, outcome).Observe(run.FinishedAt.Sub(run.StartedAt).Seconds())
+	metrics.SyncRuns.WithLabelValues(run.SiteID, run.ListID, outcome).Inc()
+	metrics.SyncRows.WithLabelValues(run.SiteID, run.ListID, "inserted").Add(float64(run.Inserted))
+	metrics.SyncRows.WithLabelValues(run.SiteID, run.ListID, "updated").Add(float64(run.Updated))
+	metrics.SyncRows.WithLabelValues(run.SiteID, run.ListID, "deleted").Add(float64(run.Deleted))
+}
@@ -0,0 +1,88 @@
+package sync
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"microsoft-apps-exporter/internal/configuration"
+	"microsoft-apps-exporter/internal/models"
+	"sync"
+	"time"
+)
+
+// Scheduler periodically reconciles configured lists, covering notifications that never arrived.
+type Scheduler struct {
+	Syncer   *Syncer
+	Interval time.Duration // Default interval between scheduled syncs of a list
+	Jitter   time.Duration // Upper bound of the random delay added to every interval
+}
+
+// NewScheduler creates a new Scheduler using the intervals from configuration.
+func NewScheduler(syncer *Syncer) *Scheduler {
+	config := configuration.GetConfig()
+	return &Scheduler{Syncer: syncer, Interval: config.SYNC_INTERVAL, Jitter: config.SYNC_JITTER}
+}
+
+// Run schedules every configured list and blocks until the context is cancelled.
+func (sc *Scheduler) Run(ctx context.Context) {
+	config := configuration.GetConfig()
+	if config.Sharepoint == nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, list := range config.Sharepoint.Lists {
+		interval := sc.listInterval(list)
+		if interval <= 0 {
+			slog.Info("Scheduled sync disabled for list", "site_id", list.SiteID, "list_id", list.ListID, "operation", "schedule")
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sc.scheduleList(ctx, list, interval)
+		}()
+	}
+
+	wg.Wait()
+	slog.Info("Sync scheduler stopped", "operation", "schedule")
+}
+
+// scheduleList runs the reconciliation loop of a single list until the context is cancelled.
+func (sc *Scheduler) scheduleList(ctx context.Context, list models.ListReference, interval time.Duration) {
+	slog.Info("Scheduled sync enabled for list", "site_id", list.SiteID, "list_id", list.ListID,
+		"interval", interval.String(), "jitter", sc.Jitter.String(), "operation", "schedule")
+
+	for {
+		timer := time.NewTimer(interval + sc.jitter())
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !sc.Syncer.Queue.EnqueueIdle(SyncJob{List: list, Trigger: TriggerSchedule}) {
+			slog.Info("Scheduled sync skipped, list is already being synchronized",
+				"site_id", list.SiteID, "list_id", list.ListID, "operation", "schedule")
+		}
+	}
+}
+
+// listInterval returns the list specific interval if configured, otherwise the default one.
+func (sc *Scheduler) listInterval(list models.ListReference) time.Duration {
+	if list.SyncInterval != 0 {
+		return list.SyncInterval
+	}
+	return sc.Interval
+}
+
+// jitter returns a random delay in the [0, Jitter) range.
+func (sc *Scheduler) jitter() time.Duration {
+	if sc.Jitter <= 0 {
+		return 0
+	}
+	return rand.N(sc.Jitter)
+}
@@ -1,142 +1,318 @@
 package sync
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"microsoft-apps-exporter/internal/api"
 	"microsoft-apps-exporter/internal/models"
+	"microsoft-apps-exporter/internal/schema"
+	"microsoft-apps-exporter/internal/tracing"
+	"microsoft-apps-exporter/internal/transform"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// SyncSharepoint synchronizes a SharePoint list and its items for a given site and list ID.
-func (s *Syncer) SyncSharepoint(list models.ListReference) error {
+// syncJob runs a queued sync job and records its failure for a later retry.
+// A sync cancelled by Shutdown is stored as pending instead, so it resumes on the next start.
+// A resumed pending sync is removed from the stored ones once it has run, successfully or recorded as failed.
+func (s *Syncer) syncJob(ctx context.Context, job SyncJob) error {
+	done := s.trackActive(job)
+	defer done()
+
+	ctx = trace.ContextWithSpanContext(ctx, job.SpanContext)
+	err := s.syncSharepoint(ctx, job)
+	if err != nil && ctx.Err() != nil {
+		// Cancelled by Shutdown, the job is resumed on the next start instead of being retried
+		s.interrupt(job)
+		return err
+	}
+
+	bookkeepingCtx := context.WithoutCancel(ctx)
+	s.Retrier.track(bookkeepingCtx, job, err)
+	if job.Pending {
+		s.completePending(bookkeepingCtx, job)
+	}
+	return err
+}
+
+// syncSharepoint runs the sync job while holding the list lock and records it as a sync run.
+// The sync is bounded by the sync timeout, its bookkeeping outlives the cancellation of the context.
+func (s *Syncer) syncSharepoint(ctx context.Context, job SyncJob) (err error) {
+	list := job.List
+	ctx, span := tracing.Start(ctx, "sync.sharepoint", append(listAttributes(list),
+		attribute.String("trigger", string(job.Trigger)))...)
+	bookkeepingCtx := context.WithoutCancel(ctx)
+
+	unlock := s.lockList(list)
+	defer unlock()
+
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	run := newSyncRun(job)
+	defer func() {
+		s.recordRun(bookkeepingCtx, run, err)
+		span.SetAttributes(attribute.Bool("full", run.Full), attribute.Int("pages", run.Pages),
+			attribute.Int("changes.inserted", run.Inserted), attribute.Int("changes.updated", run.Updated),
+			attribute.Int("changes.deleted", run.Deleted))
+		tracing.End(span, err)
+	}()
 
 	slog.Info("Syncing SharePoint list", "site_id", list.SiteID, "list_id", list.ListID,
 		"database_table", list.DbTableName, "operation", "sync")
 
-	if err := s.syncList(list); err != nil {
+	if err := s.syncList(ctx, list); err != nil {
 		return fmt.Errorf("failed to sync list: %w", err)
 	}
 
-	if err := s.syncListItems(list); err != nil {
-		if cleanupErr := s.Database.DeleteDeltaLink(list.ListID); cleanupErr != nil {
-			return fmt.Errorf("failed to sync list items: %w; cleanup failed: %v", err, cleanupErr)
-		}
+	transforms, err := transform.NewPipeline(list)
+	if err != nil {
+		return fmt.Errorf("invalid transforms: %w", err)
+	}
 
-		slog.Info("Delta link cleared due to encountered exception during sync operation",
-			"site_id", list.SiteID, "list_id", list.ListID, "operation", "sync")
+	location, err := list.Location()
+	if err != nil {
+		return err
+	}
 
+	columns, err := s.checkSchema(ctx, list, transforms)
+	if err != nil {
+		return fmt.Errorf("failed to check schema: %w", err)
+	}
+
+	// The item changes and the new delta link are committed together, a failed sync resumes from the previous link
+	fields := fieldsPipeline{coercer: schema.NewFieldCoercer(columns, location), transforms: transforms}
+	if err := s.syncListItems(ctx, list, job.Full, fields, run); err != nil {
 		return fmt.Errorf("failed to sync list items: %w", err)
 	}
 
 	return nil
 }
 
+// fieldsPipeline prepares the fields of the list items for their table.
+type fieldsPipeline struct {
+	coercer    *schema.FieldCoercer // Converts the fields to the types of their SharePoint columns
+	transforms *transform.Pipeline  // Computes the table columns from the converted fields
+}
+
 // syncList synchronizes the metadata of a SharePoint list.
-func (s *Syncer) syncList(list models.ListReference) error {
-	dbList, err := s.Database.GetList(list.ListID)
+func (s *Syncer) syncList(ctx context.Context, list models.ListReference) (err error) {
+	ctx, span := tracing.Start(ctx, "sync.list", listAttributes(list)...)
+	defer func() { tracing.End(span, err) }()
+
+	graphCtx, graphSpan := tracing.Start(ctx, "graph.GetList", listAttributes(list)...)
+	apiList, err := s.Graph.GetList(graphCtx, list.SiteID, list.ListID)
+	tracing.End(graphSpan, err)
 	if err != nil {
-		return fmt.Errorf("failed to retrieve list from database: %w", err)
+		return fmt.Errorf("failed to retrieve list from API: %w", err)
 	}
 
-	apiList, err := s.Graph.GetList(list.SiteID, list.ListID)
+	// The delta link of an existing list is kept
+	counts, err := s.Database.UpsertLists(ctx, apiList)
 	if err != nil {
-		return fmt.Errorf("failed to retrieve list from API: %w", err)
+		return fmt.Errorf("failed to upsert: %w", err)
 	}
 
-	// Calculate the differences between the database and API lists
-	toInsert, toUpdate, toDelete := diffFull(
-		dbList, apiList,
-		func(l models.ListMetadata) string { return l.ID },
-		func(l models.ListMetadata) string { return l.ETag },
-	)
+	slog.Info("Synced SharePoint list metadata", "site_id", list.SiteID, "list_id", list.ListID,
+		slog.Group("changes", "inserted", counts.Inserted, "updated", counts.Updated), "operation", "sync")
+	span.SetAttributes(attribute.Int("changes.inserted", counts.Inserted), attribute.Int("changes.updated", counts.Updated))
+	return nil
+}
 
-	slog.Info("Syncing SharePoint list metadata", "site_id", list.SiteID, "list_id", list.ListID,
-		slog.Group("changes", "to_insert", len(toInsert), "to_update", len(toUpdate), "to_delete", len(toDelete)),
-		"operation", "sync")
+// syncListItems synchronizes SharePoint list items page by page, collecting the statistics into the sync run.
+// The pages are buffered into batches of BatchSize items, every batch is committed along with a checkpoint,
+// so an interrupted sync resumes from the page following its last applied batch.
+// A full resync ignores the stored delta link, which is replaced once the resync completes.
+// The fields of the items are prepared for the table by the fields pipeline.
+func (s *Syncer) syncListItems(ctx context.Context, list models.ListReference, full bool, fields fieldsPipeline,
+	run *models.SyncRun) (err error) {
+	ctx, span := tracing.Start(ctx, "sync.list_items", listAttributes(list)...)
+	defer func() { tracing.End(span, err) }()
 
-	if err := s.Database.InsertLists(&toInsert); err != nil {
-		return fmt.Errorf("failed to insert: %w", err)
+	checkpoint, err := s.Database.GetSyncCheckpoint(ctx, list.SiteID, list.ListID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve checkpoint: %w", err)
+	}
+	if checkpoint != nil && full && !checkpoint.Full {
+		// The requested full resync supersedes the interrupted delta sync
+		if err := s.Database.DeleteSyncCheckpoint(ctx, list.SiteID, list.ListID); err != nil {
+			return fmt.Errorf("failed to discard checkpoint: %w", err)
+		}
+		checkpoint = nil
 	}
 
-	for _, list := range toUpdate {
-		if err := s.Database.UpdateListIgnoreDelta(list); err != nil {
-			return fmt.Errorf("failed to update: %w", err)
+	var link *string
+	progress := models.SyncProgress{Full: full, First: true}
+	switch {
+	case checkpoint != nil:
+		link = &checkpoint.NextLink
+		progress = models.SyncProgress{Full: checkpoint.Full, Pages: checkpoint.Pages}
+		slog.Info("Resuming interrupted sync", "site_id", list.SiteID, "list_id", list.ListID, "full", checkpoint.Full,
+			"pages", checkpoint.Pages, "interrupted_at", checkpoint.UpdatedAt, "operation", "sync")
+	case !full:
+		link, err = s.Database.GetDeltaLink(ctx, list.ListID)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve delta link: %w", err)
 		}
+		progress.Full = link == nil
 	}
+	run.Full = progress.Full
 
-	for _, id := range toDelete {
-		if err := s.Database.DeleteList(id); err != nil {
-			return fmt.Errorf("failed to delete: %w", err)
+	options := api.NewListItemsWithDeltaOptions(fields.transforms.Fields(), nil)
+
+	graphCtx, graphSpan := tracing.Start(ctx, "graph.GetListItemsWithDelta", append(listAttributes(list),
+		attribute.Bool("with_delta", !progress.Full), attribute.Bool("resumed", checkpoint != nil))...)
+	batch := itemsBatch{size: s.BatchSize}
+	pages, err := s.Graph.ForEachListItemsPage(graphCtx, list.SiteID, list.ListID, link, options, func(page api.ListItemsPage) error {
+		progress.Pages++
+		progress.NextLink, progress.DeltaLink = page.NextLink, page.DeltaLink
+		if !batch.add(page) {
+			return nil // Written along with the following pages
+		}
+
+		err := s.applyListItems(ctx, list, batch.take(), fields, &progress, run)
+		progress.First = false
+		return err
+	})
+	graphSpan.SetAttributes(attribute.Int("pages", pages))
+	tracing.End(graphSpan, err)
+	run.Pages += pages
+	if err != nil {
+		if checkpoint != nil && pages == 0 {
+			// The next link may have expired, the following attempt starts over
+			if cleanupErr := s.Database.DeleteSyncCheckpoint(context.WithoutCancel(ctx), list.SiteID, list.ListID); cleanupErr != nil {
+				return fmt.Errorf("failed to resume from checkpoint: %w; cleanup failed: %v", err, cleanupErr)
+			}
+			return fmt.Errorf("failed to resume from checkpoint: %w", err)
 		}
+		return fmt.Errorf("failed to sync list items: %w", err)
 	}
+
+	slog.Info("Synced SharePoint list items", "with_delta", !progress.Full, "site_id", list.SiteID, "list_id", list.ListID,
+		"pages", pages, slog.Group("changes", "inserted", run.Inserted, "updated", run.Updated, "deleted", run.Deleted),
+		"operation", "sync")
 	return nil
 }
 
-// syncListItems synchronizes SharePoint list items.
-func (s *Syncer) syncListItems(list models.ListReference) error {
-	dbTable, columnsMap := list.DbTableName, list.ColumnsMap
-	deltaLink, err := s.Database.GetDeltaLink(list.ListID)
-	if err != nil {
-		return fmt.Errorf("failed to retrieve delta link: %w", err)
-	}
+// itemsBatch buffers the items of successive delta pages until they are written together.
+type itemsBatch struct {
+	size  int // Number of items from which the batch is written, every page when zero
+	items []models.ListItem
+}
 
-	dbItems, err := s.Database.GetListItems(dbTable, list.SiteID, list.ListID)
-	if err != nil {
-		return fmt.Errorf("failed to retrieve list items from database: %w", err)
-	}
+// add buffers the items of the page and reports whether the batch is to be written, always after the last page.
+func (b *itemsBatch) add(page api.ListItemsPage) bool {
+	b.items = append(b.items, page.Items...)
+	return page.NextLink == nil || len(b.items) >= b.size
+}
 
-	expandFields := make([]string, 0, len(columnsMap))
-	for _, column := range columnsMap {
-		expandFields = append(expandFields, column)
-	}
+// take returns the buffered items and empties the batch.
+func (b *itemsBatch) take() []models.ListItem {
+	items := b.items
+	b.items = nil
+	return items
+}
 
-	options := api.NewListItemsWithDeltaOptions(expandFields, nil)
-	newDeltaLink, apiItems, err := s.Graph.GetListItemsWithDelta(list.SiteID, list.ListID, deltaLink, options)
-	if err != nil {
-		return fmt.Errorf("failed to retrieve list items from API: %w", err)
-	}
+// applyListItems applies the changes of a batch of pages along with the progress of the sync.
+// A full sync writes only the new and changed items, looking up the etags of the items of the batch.
+// The fields of the written items are converted to the types of their columns, then transformed into table columns.
+func (s *Syncer) applyListItems(ctx context.Context, list models.ListReference, items []models.ListItem,
+	fields fieldsPipeline, progress *models.SyncProgress, run *models.SyncRun) (err error) {
+	dbTable := list.DbTableName
 
-	if newDeltaLink != nil {
-		if err := s.Database.SaveDeltaLink(list.ListID, *newDeltaLink); err != nil {
-			return fmt.Errorf("failed to save delta link: %w", err)
+	var changes models.ListItemChanges
+	changes.Upsert, changes.Delete = diffDelta(items,
+		func(li models.ListItem) string { return li.Metadata.ID },
+		func(li models.ListItem) string { return li.Metadata.ETag },
+	)
+
+	// The items that do not match the filter are deleted, in case they matched before their update.
+	// A full sync does not see them either, so the items that stopped matching after a filter change are pruned.
+	matching := changes.Upsert[:0]
+	for _, item := range changes.Upsert {
+		if fields.transforms.Match(item.MappedFields) {
+			matching = append(matching, item)
+		} else {
+			changes.Delete = append(changes.Delete, item.Metadata.ID)
 		}
 	}
+	changes.Upsert = matching
 
-	// Determine insert, update, delete actions
-	var toInsert, toUpdate []models.ListItem
-	var toDelete []string
-	if deltaLink != nil { // Delta synchronization
-		toInsert, toUpdate, toDelete = diffDelta(*dbItems, *apiItems,
-			func(li models.ListItem) string { return li.Metadata.ID },
-			func(li models.ListItem) string { return li.Metadata.ETag },
-		)
-	} else { // Full synchronization
-		toInsert, toUpdate, toDelete = diffFull(*dbItems, *apiItems,
+	if progress.Full {
+		ids := make([]string, 0, len(changes.Upsert))
+		for _, item := range changes.Upsert {
+			ids = append(ids, item.Metadata.ID)
+		}
+		// The items seen by every batch are persisted, so a resumed full sync still deletes the missing ones
+		progress.SeenIDs = ids
+
+		dbCtx, dbSpan := tracing.Start(ctx, "database.GetListItemETags", attribute.String("database_table", dbTable),
+			attribute.Int("rows", len(ids)))
+		etags, err := s.Database.GetListItemETags(dbCtx, dbTable, list.SiteID, list.ListID, ids)
+		tracing.End(dbSpan, err)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve list item etags from database: %w", err)
+		}
+
+		existing := make([]models.ListItem, 0, len(etags))
+		for id, etag := range etags {
+			existing = append(existing, models.ListItem{Metadata: models.ListItemMetadata{ID: id, ETag: etag}})
+		}
+		toInsert, toUpdate, _ := diffFull(existing, changes.Upsert,
 			func(li models.ListItem) string { return li.Metadata.ID },
 			func(li models.ListItem) string { return li.Metadata.ETag },
 		)
+		changes.Upsert = append(toInsert, toUpdate...)
 	}
 
-	slog.Info("Syncing SharePoint list items",
-		"with_delta", deltaLink != nil, "site_id", list.SiteID, "list_id", list.ListID,
-		slog.Group("changes", "to_insert", len(toInsert), "to_update", len(toUpdate), "to_delete", len(toDelete)),
-		"operation", "sync")
-
-	if err := s.Database.InsertListItems(dbTable, columnsMap, &toInsert); err != nil {
-		return fmt.Errorf("failed to insert: %w", err)
-	}
+	for i, item := range changes.Upsert {
+		// The fields that cannot be converted or transformed are stored as NULL rather than failing the page
+		if err := fields.coercer.Coerce(item.MappedFields); err != nil {
+			slog.Warn("Failed to convert list item fields", "site_id", list.SiteID, "list_id", list.ListID,
+				"item_id", item.Metadata.ID, "exception", err, "operation", "sync")
+		}
 
-	for _, item := range toUpdate {
-		if err := s.Database.UpdateListItem(dbTable, columnsMap, item); err != nil {
-			return fmt.Errorf("failed to update: %w", err)
+		values, err := fields.transforms.Apply(item.MappedFields)
+		if err != nil {
+			slog.Warn("Failed to transform list item fields", "site_id", list.SiteID, "list_id", list.ListID,
+				"item_id", item.Metadata.ID, "exception", err, "operation", "sync")
 		}
+		changes.Upsert[i].MappedFields = values
 	}
 
-	for _, id := range toDelete {
-		if err := s.Database.DeleteListItem(dbTable, id); err != nil {
-			return fmt.Errorf("failed to delete: %w", err)
-		}
+	slog.Debug("Applying SharePoint list items batch", "with_delta", !progress.Full, "site_id", list.SiteID,
+		"list_id", list.ListID, "pages", progress.Pages, "last", progress.NextLink == nil,
+		slog.Group("changes", "to_upsert", len(changes.Upsert), "to_delete", len(changes.Delete)),
+		"operation", "sync")
+
+	dbCtx, dbSpan := tracing.Start(ctx, "database.ApplyListItemChanges", attribute.String("database_table", dbTable),
+		attribute.Int("pages", progress.Pages), attribute.Int("rows.upserted", len(changes.Upsert)),
+		attribute.Int("rows.deleted", len(changes.Delete)))
+	// The transformed fields are keyed by table column
+	table := list
+	table.ColumnsMap = fields.transforms.Columns()
+	counts, err := s.Database.ApplyListItemChanges(dbCtx, table, changes, *progress)
+	tracing.End(dbSpan, err)
+	if err != nil {
+		return fmt.Errorf("failed to apply changes: %w", err)
 	}
 
+	run.Inserted += counts.Inserted
+	run.Updated += counts.Updated
+	run.Deleted += counts.Deleted
 	return nil
 }
+
+// listAttributes returns the span attributes identifying the list.
+func listAttributes(list models.ListReference) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("site_id", list.SiteID),
+		attribute.String("list_id", list.ListID),
+		attribute.String("database_table", list.DbTableName),
+	}
+}
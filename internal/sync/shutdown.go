@@ -0,0 +1,161 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"microsoft-apps-exporter/internal/configuration"
+	"microsoft-apps-exporter/internal/models"
+)
+
+// Start runs the queue and the retrier in the background. The retrier stops along with the context,
+// whereas the running syncs are only cancelled by Shutdown, so they can drain.
+func (s *Syncer) Start(ctx context.Context) {
+	syncCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+	s.mu.Lock()
+	s.cancelSyncs = cancel
+	s.mu.Unlock()
+
+	go s.Queue.Run(syncCtx)
+	go s.Retrier.Run(ctx)
+}
+
+// Shutdown stops accepting syncs and waits for the running ones until the context is done, then cancels them.
+// Pending and cancelled syncs are stored in the database and queued again by ResumePending on the next start.
+func (s *Syncer) Shutdown(ctx context.Context) {
+	pending := s.Queue.Close()
+
+	s.mu.Lock()
+	cancel := s.cancelSyncs
+	running := len(s.active)
+	s.mu.Unlock()
+
+	if cancel != nil {
+		slog.Info("Draining syncs", "running", running, "pending", len(pending), "operation", "shutdown")
+
+		select {
+		case <-s.Queue.Stopped():
+		case <-ctx.Done():
+			slog.Warn("Shutdown grace period expired, cancelling running syncs", "lists", s.activeLists(),
+				"operation", "shutdown")
+			cancel()
+			<-s.Queue.Stopped()
+		}
+		cancel()
+	}
+
+	s.mu.Lock()
+	jobs := append(pending, s.interrupted...)
+	s.interrupted = nil
+	s.mu.Unlock()
+
+	s.savePending(context.WithoutCancel(ctx), jobs)
+}
+
+// ResumePending queues the syncs stored by the previous Shutdown. They stay stored until they have run,
+// so the ones interrupted by a crash are resumed on the following start.
+func (s *Syncer) ResumePending(ctx context.Context) error {
+	if s.Database == nil {
+		return nil
+	}
+
+	syncs, err := s.Database.GetPendingSyncs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve pending syncs: %w", err)
+	}
+
+	config := configuration.GetConfig()
+	for _, pending := range syncs {
+		list, found := config.Sharepoint.FindList(pending.SiteID, pending.ListID)
+		if !found {
+			slog.Info("Discarding pending sync of list no longer configured", "site_id", pending.SiteID,
+				"list_id", pending.ListID, "operation", "shutdown")
+			if err := s.Database.DeletePendingSync(ctx, pending.SiteID, pending.ListID); err != nil {
+				slog.Error("Failed to discard pending sync", "site_id", pending.SiteID, "list_id", pending.ListID,
+					"exception", err, "operation", "shutdown")
+			}
+			continue
+		}
+
+		s.Queue.Enqueue(SyncJob{List: list, Full: pending.Full, Trigger: SyncTrigger(pending.Trigger), Pending: true})
+		slog.Info("Resuming pending sync", "site_id", list.SiteID, "list_id", list.ListID, "full", pending.Full,
+			"queued_at", pending.QueuedAt, "operation", "shutdown")
+	}
+	return nil
+}
+
+// savePending stores the jobs left unfinished at shutdown.
+func (s *Syncer) savePending(ctx context.Context, jobs []SyncJob) {
+	if len(jobs) == 0 {
+		return
+	}
+	if s.Database == nil {
+		slog.Warn("Discarding pending syncs without a database", "count", len(jobs), "operation", "shutdown")
+		return
+	}
+
+	syncs := make([]models.PendingSync, 0, len(jobs))
+	for _, job := range jobs {
+		syncs = append(syncs, models.PendingSync{
+			SiteID:  job.List.SiteID,
+			ListID:  job.List.ListID,
+			Full:    job.Full,
+			Trigger: string(job.Trigger),
+		})
+	}
+
+	if err := s.Database.SavePendingSyncs(ctx, syncs); err != nil {
+		slog.Error("Failed to store pending syncs", "count", len(syncs), "exception", err, "operation", "shutdown")
+		return
+	}
+	slog.Info("Pending syncs stored for the next start", "count", len(syncs), "operation", "shutdown")
+}
+
+// completePending removes the stored pending sync of the job once it has run.
+func (s *Syncer) completePending(ctx context.Context, job SyncJob) {
+	if s.Database == nil {
+		return
+	}
+	if err := s.Database.DeletePendingSync(ctx, job.List.SiteID, job.List.ListID); err != nil {
+		slog.Error("Failed to remove resumed pending sync", "site_id", job.List.SiteID, "list_id", job.List.ListID,
+			"exception", err, "operation", "shutdown")
+	}
+}
+
+// trackActive registers the job as running until the returned function is called.
+func (s *Syncer) trackActive(job SyncJob) func() {
+	key := listKey(job.List)
+
+	s.mu.Lock()
+	if s.active == nil {
+		s.active = make(map[string]SyncJob)
+	}
+	s.active[key] = job
+	s.mu.Unlock()
+
+	return func() {
+		s.mu.Lock()
+		delete(s.active, key)
+		s.mu.Unlock()
+	}
+}
+
+// interrupt records a job cancelled by Shutdown.
+func (s *Syncer) interrupt(job SyncJob) {
+	s.mu.Lock()
+	s.interrupted = append(s.interrupted, job)
+	s.mu.Unlock()
+}
+
+// activeLists returns the resources of the lists being synchronized.
+func (s *Syncer) activeLists() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lists := make([]string, 0, len(s.active))
+	for key := range s.active {
+		lists = append(lists, key)
+	}
+	return lists
+}
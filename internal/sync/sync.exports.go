@@ -4,10 +4,45 @@
 // This file is only included in builds with the "testing" tag.
 package sync
 
+import (
+	"context"
+	"microsoft-apps-exporter/internal/api"
+	"microsoft-apps-exporter/internal/models"
+	"time"
+)
+
 func DiffFull[T any](existing, incoming []T, getID, getETag func(T) string) (toInsert, toUpdate []T, toDelete []string) {
 	return diffFull(existing, incoming, getID, getETag)
 }
 
-func DiffDelta[T any](existing, changes []T, getID, getETag func(T) string) (toInsert, toUpdate []T, toDelete []string) {
-	return diffDelta(existing, changes, getID, getETag)
+func DiffDelta[T any](changes []T, getID, getETag func(T) string) (toUpsert []T, toDelete []string) {
+	return diffDelta(changes, getID, getETag)
+}
+
+func (sc *Scheduler) ListInterval(list models.ListReference) time.Duration {
+	return sc.listInterval(list)
+}
+
+func (sc *Scheduler) NextJitter() time.Duration {
+	return sc.jitter()
+}
+
+func (r *Retrier) NextAttempt(attempts int, now time.Time) *time.Time {
+	return r.nextAttempt(attempts, now)
+}
+
+// BatchPages buffers the delta pages like a sync does and returns the batches of items it would write.
+func (s *Syncer) BatchPages(pages []api.ListItemsPage) [][]models.ListItem {
+	batch := itemsBatch{size: s.BatchSize}
+	var batches [][]models.ListItem
+	for _, page := range pages {
+		if batch.add(page) {
+			batches = append(batches, batch.take())
+		}
+	}
+	return batches
+}
+
+func (s *Syncer) RecordRun(ctx context.Context, run *models.SyncRun, syncErr error) {
+	s.recordRun(ctx, run, syncErr)
 }
@@ -1,40 +1,105 @@
 package sync
 
 import (
-	"fmt"
+	"context"
 	"log/slog"
 	"microsoft-apps-exporter/internal/api"
 	"microsoft-apps-exporter/internal/configuration"
 	"microsoft-apps-exporter/internal/database"
+	"microsoft-apps-exporter/internal/models"
+	"microsoft-apps-exporter/internal/schema"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Syncer is responsible for synchronizing data between the database and MS Graph API.
 type Syncer struct {
 	Graph    *api.GraphHelper
 	Database *database.Database
+	Queue    *SyncQueue
+	Retrier  *Retrier
+	Timeout  time.Duration // Deadline of the sync of a single list, none when zero
+
+	// Number of list items from which the buffered delta pages are written, every page is written when zero.
+	// It follows the bulk threshold of the database, so the batches are loaded with COPY.
+	BatchSize int
+
+	DriftPolicy schema.DriftPolicy // What a sync does about a schema drift of its list
+
+	mu          sync.Mutex
+	listLocks   map[string]*sync.Mutex
+	active      map[string]SyncJob // Jobs being run by the queue
+	interrupted []SyncJob          // Jobs cancelled by Shutdown, stored as pending
+	cancelSyncs context.CancelFunc // Cancels the syncs run by the queue, set by Start
 }
 
 // NewSyncer creates a new Syncer with the provided database and API clients.
 func NewSyncer(graph *api.GraphHelper, db *database.Database) *Syncer {
-	return &Syncer{Graph: graph, Database: db}
+	config := configuration.GetConfig()
+
+	driftPolicy, err := schema.ParseDriftPolicy(config.SYNC_SCHEMA_DRIFT_POLICY)
+	if err != nil {
+		slog.Error("Invalid schema drift policy, using default", "exception", err, "default", schema.DriftPolicyWarn, "operation", "sync")
+		driftPolicy = schema.DriftPolicyWarn
+	}
+
+	s := &Syncer{Graph: graph, Database: db, Timeout: config.SYNC_TIMEOUT, BatchSize: config.DB_BULK_THRESHOLD,
+		DriftPolicy: driftPolicy}
+	s.Queue = NewSyncQueue(s.syncJob, config.SYNC_MAX_CONCURRENCY)
+	s.Retrier = NewRetrier(s)
+	return s
 }
 
-// SyncResources synchronizes all resources from config between the database and the API.
-func (s *Syncer) SyncResources() error {
+// SyncResources queues the sync of all resources from config between the database and the API.
+// The syncs run like any queued one: drained on shutdown, stored as pending when interrupted,
+// and a list failing to sync, e.g. on a schema drift with the fail policy, is recorded for a retry and skipped.
+// A sync resumed from the previous shutdown is coalesced with the one of its list.
+func (s *Syncer) SyncResources(ctx context.Context) {
 	config := configuration.GetConfig()
 	slog.Info("Starting resource synchronization", "operation", "sync")
 
+	queued := 0
 	if config.Sharepoint != nil {
 		slog.Debug("SharePoint resource found in config", "database_table", config.Sharepoint.DbTableName, "operation", "sync")
 
 		for _, list := range config.Sharepoint.Lists {
-			if err := s.SyncSharepoint(list); err != nil {
-				return fmt.Errorf("failed to sync SharePoint resource: %w", err)
+			if s.EnqueueSync(ctx, list, TriggerStartup) {
+				queued++
 			}
 		}
 	}
 
-	slog.Info("Initial resource synchronization completed. Further sync will occur on webhook Change Notificaiton.", "operation", "sync")
+	slog.Info("Initial resource synchronization queued. Further sync will occur on webhook Change Notificaiton.",
+		"queued", queued, "operation", "sync")
+}
+
+// EnqueueSync queues a sync of the list, coalescing it with an already pending one.
+// The sync is traced as a child of the span in the context.
+func (s *Syncer) EnqueueSync(ctx context.Context, list models.ListReference, trigger SyncTrigger) bool {
+	return s.Queue.Enqueue(SyncJob{List: list, Trigger: trigger, SpanContext: trace.SpanContextFromContext(ctx)})
+}
+
+// EnqueueResync queues a full resync of the list, discarding its delta link.
+// The sync is traced as a child of the span in the context.
+func (s *Syncer) EnqueueResync(ctx context.Context, list models.ListReference, trigger SyncTrigger) bool {
+	return s.Queue.Enqueue(SyncJob{List: list, Full: true, Trigger: trigger, SpanContext: trace.SpanContextFromContext(ctx)})
+}
+
+// lockList serializes syncs of the same list, including the ones started outside of the queue.
+func (s *Syncer) lockList(list models.ListReference) func() {
+	s.mu.Lock()
+	if s.listLocks == nil {
+		s.listLocks = make(map[string]*sync.Mutex)
+	}
+	lock, ok := s.listLocks[listKey(list)]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.listLocks[listKey(list)] = lock
+	}
+	s.mu.Unlock()
 
-	return nil
+	lock.Lock()
+	return lock.Unlock
 }
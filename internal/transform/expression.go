@@ -0,0 +1,300 @@
+package transform
+
+import (
+	"fmt"
+	"microsoft-apps-exporter/internal/models"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expression is a parsed transform, a pipeline of function calls separated by "|".
+// The first call receives the value of the mapped field, nil for a computed column,
+// every following call the result of the previous one.
+// The arguments are string literals in double quotes, numbers, true, false, null or SharePoint field names.
+//
+//	trim | lower
+//	concat(FirstName, " ", LastName) | trim
+//	field(Score) | number | default(0)
+type Expression struct {
+	source string
+	calls  []call
+}
+
+type call struct {
+	name string
+	fn   function
+	args []argument
+}
+
+// argument is either a literal or a reference to a field of the item.
+type argument struct {
+	field   string
+	literal any
+}
+
+// Parse parses and validates a transform expression.
+func Parse(source string) (*Expression, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{tokens: tokens}
+	expr := &Expression{source: source}
+	for {
+		c, err := p.call()
+		if err != nil {
+			return nil, err
+		}
+		expr.calls = append(expr.calls, c)
+
+		if p.done() {
+			return expr, nil
+		}
+		if !p.accept(tokenPipe) {
+			return nil, fmt.Errorf("expected | at %q", p.peek().text)
+		}
+	}
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Fields returns the fields referenced by the arguments of the expression.
+func (e *Expression) Fields() []string {
+	var fields []string
+	for _, c := range e.calls {
+		for _, arg := range c.args {
+			if arg.field != "" {
+				fields = append(fields, arg.field)
+			}
+		}
+	}
+	return fields
+}
+
+// Type returns the PostgreSQL type of the result of the expression given the type of the input value,
+// empty for a computed column. A result of unknown type is text.
+func (e *Expression) Type(input string) string {
+	result := input
+	for _, c := range e.calls {
+		if c.fn.result != "" {
+			result = c.fn.result
+		}
+	}
+	if result == "" {
+		return typeText
+	}
+	return result
+}
+
+// Eval evaluates the expression on the input value, resolving the field arguments from the fields of the item.
+func (e *Expression) Eval(input any, fields models.ListItemMappedFields) (any, error) {
+	value := input
+	for _, c := range e.calls {
+		args := make([]any, len(c.args))
+		for i, arg := range c.args {
+			if arg.field != "" {
+				args[i] = fields[arg.field]
+			} else {
+				args[i] = arg.literal
+			}
+		}
+
+		var err error
+		value, err = c.fn.call(value, args)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.name, err)
+		}
+	}
+	return value, nil
+}
+
+type tokenKind int
+
+const (
+	tokenIdent tokenKind = iota
+	tokenString
+	tokenNumber
+	tokenLParen
+	tokenRParen
+	tokenComma
+	tokenPipe
+	tokenLBracket
+	tokenRBracket
+	tokenOperator
+	tokenEOF
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// tokenize splits the source of an expression or a filter predicate into tokens.
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokenLParen, "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenRParen, ")"})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{tokenComma, ","})
+			i++
+		case r == '|':
+			tokens = append(tokens, token{tokenPipe, "|"})
+			i++
+		case r == '[':
+			tokens = append(tokens, token{tokenLBracket, "["})
+			i++
+		case r == ']':
+			tokens = append(tokens, token{tokenRBracket, "]"})
+			i++
+		case r == '=' || r == '!' || r == '<' || r == '>':
+			end := i + 1
+			if end < len(runes) && runes[end] == '=' {
+				end++
+			}
+			if op := string(runes[i:end]); op != "!" {
+				tokens = append(tokens, token{tokenOperator, op})
+				i = end
+				continue
+			}
+			return nil, fmt.Errorf("unexpected character %q at %d", r, i)
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				if runes[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			tokens = append(tokens, token{tokenString, string(runes[i : end+1])})
+			i = end + 1
+		case r == '-' || unicode.IsDigit(r):
+			end := i + 1
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, token{tokenNumber, string(runes[i:end])})
+			i = end
+		case r == '_' || unicode.IsLetter(r):
+			end := i + 1
+			for end < len(runes) && (runes[end] == '_' || unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end])) {
+				end++
+			}
+			tokens = append(tokens, token{tokenIdent, string(runes[i:end])})
+			i = end
+		default:
+			return nil, fmt.Errorf("unexpected character %q at %d", r, i)
+		}
+	}
+	return append(tokens, token{tokenEOF, ""}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) accept(kind tokenKind) bool {
+	if p.peek().kind != kind {
+		return false
+	}
+	p.next()
+	return true
+}
+
+func (p *parser) done() bool {
+	return p.peek().kind == tokenEOF
+}
+
+// call parses a function call, the parentheses are optional without arguments.
+func (p *parser) call() (call, error) {
+	name := p.next()
+	if name.kind != tokenIdent {
+		return call{}, fmt.Errorf("expected function name at %q", name.text)
+	}
+	fn, ok := functions[name.text]
+	if !ok {
+		return call{}, fmt.Errorf("unknown function %s", name.text)
+	}
+
+	c := call{name: name.text, fn: fn}
+	if p.accept(tokenLParen) && !p.accept(tokenRParen) {
+		for {
+			arg, err := p.argument()
+			if err != nil {
+				return call{}, fmt.Errorf("%s: %w", name.text, err)
+			}
+			c.args = append(c.args, arg)
+
+			if p.accept(tokenRParen) {
+				break
+			}
+			if !p.accept(tokenComma) {
+				return call{}, fmt.Errorf("%s: expected , or ) at %q", name.text, p.peek().text)
+			}
+		}
+	}
+
+	if len(c.args) < fn.minArgs || (fn.maxArgs >= 0 && len(c.args) > fn.maxArgs) {
+		return call{}, fmt.Errorf("%s: wrong number of arguments %d", name.text, len(c.args))
+	}
+	return c, nil
+}
+
+func (p *parser) argument() (argument, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		value, err := strconv.Unquote(t.text)
+		if err != nil {
+			return argument{}, fmt.Errorf("invalid string %s", t.text)
+		}
+		return argument{literal: value}, nil
+	case tokenNumber:
+		value, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return argument{}, fmt.Errorf("invalid number %s", t.text)
+		}
+		return argument{literal: value}, nil
+	case tokenIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return argument{literal: true}, nil
+		case "false":
+			return argument{literal: false}, nil
+		case "null":
+			return argument{literal: nil}, nil
+		}
+		return argument{field: t.text}, nil
+	default:
+		return argument{}, fmt.Errorf("expected argument at %q", t.text)
+	}
+}
@@ -0,0 +1,270 @@
+package transform
+
+import (
+	"errors"
+	"fmt"
+	"microsoft-apps-exporter/internal/models"
+	"strconv"
+	"strings"
+)
+
+// Filter selects the list items to export, the ones matching every predicate.
+// A predicate compares a SharePoint field with values, a bare word being a string:
+//
+//	Status in [Approved, Closed]
+//	Confidential != true
+//	Score >= 2.5
+//	Category not in ["Draft", null]
+//
+// Text is compared case insensitively and a field holding several values matches when any of them does.
+// The ordering operators compare numbers, or text otherwise, which orders ISO dates.
+type Filter struct {
+	predicates []predicate
+}
+
+type predicate struct {
+	source string
+	field  string
+	op     string // One of = != < <= > >= in, "not in"
+	values []any
+}
+
+// ParseFilter parses and validates the predicates of a filter. A filter without predicates matches every item.
+func ParseFilter(predicates []string) (*Filter, error) {
+	f := &Filter{}
+	var errs []error
+	for _, source := range predicates {
+		pred, err := parsePredicate(source)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("filter %q: %w", source, err))
+			continue
+		}
+		f.predicates = append(f.predicates, pred)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Fields returns the fields the predicates compare.
+func (f *Filter) Fields() []string {
+	fields := make([]string, len(f.predicates))
+	for i, pred := range f.predicates {
+		fields[i] = pred.field
+	}
+	return fields
+}
+
+// Match reports whether the fields of an item match every predicate.
+func (f *Filter) Match(fields models.ListItemMappedFields) bool {
+	for _, pred := range f.predicates {
+		if !pred.match(fields[pred.field]) {
+			return false
+		}
+	}
+	return true
+}
+
+func parsePredicate(source string) (predicate, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return predicate{}, err
+	}
+	p := parser{tokens: tokens}
+
+	field := p.next()
+	if field.kind != tokenIdent {
+		return predicate{}, fmt.Errorf("expected field name at %q", field.text)
+	}
+	pred := predicate{source: source, field: field.text}
+
+	op := p.next()
+	switch {
+	case op.kind == tokenOperator:
+		if !isComparison(op.text) {
+			return predicate{}, fmt.Errorf("unknown operator %s", op.text)
+		}
+		pred.op = op.text
+		value, err := p.filterValue()
+		if err != nil {
+			return predicate{}, err
+		}
+		pred.values = []any{value}
+	case op.kind == tokenIdent && (op.text == "in" || op.text == "not"):
+		pred.op = "in"
+		if op.text == "not" {
+			if next := p.next(); next.kind != tokenIdent || next.text != "in" {
+				return predicate{}, fmt.Errorf("expected in at %q", next.text)
+			}
+			pred.op = "not in"
+		}
+		if pred.values, err = p.filterValues(); err != nil {
+			return predicate{}, err
+		}
+	default:
+		return predicate{}, fmt.Errorf("expected operator at %q", op.text)
+	}
+
+	if !p.done() {
+		return predicate{}, fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	if isOrdering(pred.op) && pred.values[0] == nil {
+		return predicate{}, fmt.Errorf("%s cannot compare with null", pred.op)
+	}
+	return pred, nil
+}
+
+// filterValues parses a bracketed list of values.
+func (p *parser) filterValues() ([]any, error) {
+	if !p.accept(tokenLBracket) {
+		return nil, fmt.Errorf("expected [ at %q", p.peek().text)
+	}
+
+	var values []any
+	for {
+		value, err := p.filterValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		if p.accept(tokenRBracket) {
+			return values, nil
+		}
+		if !p.accept(tokenComma) {
+			return nil, fmt.Errorf("expected , or ] at %q", p.peek().text)
+		}
+	}
+}
+
+// filterValue parses a literal, a bare word is a string.
+func (p *parser) filterValue() (any, error) {
+	arg, err := p.argument()
+	if err != nil {
+		return nil, err
+	}
+	if arg.field != "" {
+		return arg.field, nil
+	}
+	return arg.literal, nil
+}
+
+func (pred predicate) match(value any) bool {
+	switch pred.op {
+	case "in", "=":
+		return matchAny(value, pred.values)
+	case "not in", "!=":
+		return !matchAny(value, pred.values)
+	default:
+		if values, ok := value.([]any); ok {
+			for _, v := range values {
+				if compareValues(pred.op, v, pred.values[0]) {
+					return true
+				}
+			}
+			return false
+		}
+		return compareValues(pred.op, value, pred.values[0])
+	}
+}
+
+// matchAny reports whether the value, or any of several values, equals one of the predicate values.
+func matchAny(value any, predicateValues []any) bool {
+	values, ok := value.([]any)
+	if !ok {
+		values = []any{value}
+	} else if len(values) == 0 {
+		values = []any{nil} // No value at all is null
+	}
+
+	for _, v := range values {
+		for _, predicateValue := range predicateValues {
+			if equalValues(v, predicateValue) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// equalValues compares a value with a predicate value, empty text being null.
+func equalValues(value, predicateValue any) bool {
+	if value == "" {
+		value = nil
+	}
+	if predicateValue == "" {
+		predicateValue = nil
+	}
+	if value == nil || predicateValue == nil {
+		return value == nil && predicateValue == nil
+	}
+
+	switch p := predicateValue.(type) {
+	case float64:
+		number, ok := toNumber(value)
+		return ok && number == p
+	case bool:
+		if b, ok := value.(bool); ok {
+			return b == p
+		}
+		return strings.EqualFold(toString(value), strconv.FormatBool(p))
+	default:
+		return strings.EqualFold(toString(value), toString(predicateValue))
+	}
+}
+
+// compareValues orders the value and the predicate value, as numbers when both are, as text otherwise.
+func compareValues(op string, value, predicateValue any) bool {
+	if value == nil {
+		return false
+	}
+
+	var cmp int
+	number, ok := toNumber(value)
+	predicateNumber, predicateOk := toNumber(predicateValue)
+	if ok && predicateOk {
+		switch {
+		case number < predicateNumber:
+			cmp = -1
+		case number > predicateNumber:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(strings.ToLower(toString(value)), strings.ToLower(toString(predicateValue)))
+	}
+
+	switch op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	default:
+		return false
+	}
+}
+
+func toNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return number, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// isComparison reports whether the operator compares with a single value.
+func isComparison(op string) bool {
+	return op == "=" || op == "!=" || isOrdering(op)
+}
+
+func isOrdering(op string) bool {
+	return op == "<" || op == "<=" || op == ">" || op == ">="
+}
@@ -0,0 +1,111 @@
+package transform
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PostgreSQL types of the values returned by the functions.
+const (
+	typeText   = "TEXT"
+	typeNumber = "DOUBLE PRECISION"
+)
+
+// function is a built-in function of the expressions, called with the piped value and its arguments.
+type function struct {
+	minArgs int
+	maxArgs int    // Unbounded when negative
+	result  string // PostgreSQL type of the returned value, empty when it is the one of the piped value
+	call    func(input any, args []any) (any, error)
+}
+
+var functions = map[string]function{
+	// trim removes the leading and trailing spaces
+	"trim": {result: typeText, call: stringFunction(strings.TrimSpace)},
+	// lower converts to lower case
+	"lower": {result: typeText, call: stringFunction(strings.ToLower)},
+	// upper converts to upper case
+	"upper": {result: typeText, call: stringFunction(strings.ToUpper)},
+	// number parses a number from text, empty text is null
+	"number": {result: typeNumber, call: number},
+	// default(value) replaces null and empty text
+	"default": {minArgs: 1, maxArgs: 1, call: defaultValue},
+	// concat(values...) appends the values to the piped text, null values are skipped
+	"concat": {minArgs: 1, maxArgs: -1, result: typeText, call: concat},
+	// replace(old, new) replaces every occurrence of old
+	"replace": {minArgs: 2, maxArgs: 2, result: typeText, call: replace},
+	// field(Name) discards the piped value for the value of another field, whose type is unknown, so stored as text
+	"field": {minArgs: 1, maxArgs: 1, result: typeText, call: func(_ any, args []any) (any, error) { return args[0], nil }},
+}
+
+// stringFunction applies fn to the value as text, null is kept.
+func stringFunction(fn func(string) string) func(any, []any) (any, error) {
+	return func(input any, _ []any) (any, error) {
+		if input == nil {
+			return nil, nil
+		}
+		return fn(toString(input)), nil
+	}
+}
+
+func number(input any, _ []any) (any, error) {
+	switch v := input.(type) {
+	case nil:
+		return nil, nil
+	case float64:
+		return v, nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil, nil
+		}
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", v)
+		}
+		return parsed, nil
+	default:
+		return nil, fmt.Errorf("invalid number of type %T", input)
+	}
+}
+
+func defaultValue(input any, args []any) (any, error) {
+	if input == nil || input == "" {
+		return args[0], nil
+	}
+	return input, nil
+}
+
+func concat(input any, args []any) (any, error) {
+	var b strings.Builder
+	for _, value := range append([]any{input}, args...) {
+		if value != nil {
+			b.WriteString(toString(value))
+		}
+	}
+	return b.String(), nil
+}
+
+func replace(input any, args []any) (any, error) {
+	if input == nil {
+		return nil, nil
+	}
+	return strings.ReplaceAll(toString(input), toString(args[0]), toString(args[1])), nil
+}
+
+// toString formats a field value as text.
+func toString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,112 @@
+package transform
+
+import (
+	"errors"
+	"fmt"
+	"microsoft-apps-exporter/internal/models"
+	"slices"
+	"sort"
+)
+
+// Pipeline computes the values of the table columns of a list from the fields of its items.
+type Pipeline struct {
+	columnsMap map[string]string
+	transforms map[string]*Expression // Keyed by table column
+	filter     *Filter
+}
+
+// NewPipeline parses the filter and the transforms of the list. A transformed column of the columns map
+// transforms its field, any other one is computed from the fields its expression refers to.
+func NewPipeline(list models.ListReference) (*Pipeline, error) {
+	p := &Pipeline{columnsMap: list.ColumnsMap, transforms: make(map[string]*Expression, len(list.Transforms))}
+
+	var errs []error
+	filter, err := ParseFilter(list.Filter)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	p.filter = filter
+
+	metadataColumns := models.ListItemMetadata{}.DbColumns()
+	for dbColumn, source := range list.Transforms {
+		if slices.Contains(metadataColumns, dbColumn) {
+			errs = append(errs, fmt.Errorf("transform of column %s: metadata columns cannot be transformed", dbColumn))
+			continue
+		}
+
+		expr, err := Parse(source)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("transform of column %s: %w", dbColumn, err))
+			continue
+		}
+		p.transforms[dbColumn] = expr
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Fields returns the SharePoint fields to retrieve, the mapped ones and the ones the filter and the transforms
+// refer to, sorted.
+func (p *Pipeline) Fields() []string {
+	fields := make([]string, 0, len(p.columnsMap))
+	for _, field := range p.columnsMap {
+		fields = append(fields, field)
+	}
+	fields = append(fields, p.filter.Fields()...)
+	for _, expr := range p.transforms {
+		fields = append(fields, expr.Fields()...)
+	}
+
+	sort.Strings(fields)
+	return slices.Compact(fields)
+}
+
+// Match reports whether the item is exported according to the filter of the list, given its SharePoint fields.
+func (p *Pipeline) Match(fields models.ListItemMappedFields) bool {
+	return p.filter.Match(fields)
+}
+
+// Columns returns the columns map of the values computed by Apply, every table column mapped to itself.
+func (p *Pipeline) Columns() map[string]string {
+	columns := make(map[string]string, len(p.columnsMap)+len(p.transforms))
+	for dbColumn := range p.columnsMap {
+		columns[dbColumn] = dbColumn
+	}
+	for dbColumn := range p.transforms {
+		columns[dbColumn] = dbColumn
+	}
+	return columns
+}
+
+// Type returns the PostgreSQL type of the table column given the type of its mapped field, empty for a computed column.
+// A column without transform has the type of its field.
+func (p *Pipeline) Type(dbColumn, fieldType string) string {
+	expr, ok := p.transforms[dbColumn]
+	if !ok {
+		return fieldType
+	}
+	return expr.Type(fieldType)
+}
+
+// Apply computes the values of the table columns from the fields of an item, keyed by table column.
+// A column whose transform fails is null, the returned error joins the failures of every such column.
+func (p *Pipeline) Apply(fields models.ListItemMappedFields) (models.ListItemMappedFields, error) {
+	values := make(models.ListItemMappedFields, len(p.columnsMap)+len(p.transforms))
+	for dbColumn, field := range p.columnsMap {
+		values[dbColumn] = fields[field]
+	}
+
+	var errs []error
+	for dbColumn, expr := range p.transforms {
+		value, err := expr.Eval(values[dbColumn], fields)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("column %s: %w", dbColumn, err))
+		}
+		values[dbColumn] = value
+	}
+
+	return values, errors.Join(errs...)
+}
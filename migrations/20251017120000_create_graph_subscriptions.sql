@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS graph_subscriptions (
+    id                          VARCHAR(40)  NOT NULL PRIMARY KEY,
+    resource                    TEXT         NOT NULL,
+    notification_url            TEXT         NOT NULL,
+    lifecycle_notification_url  TEXT         NOT NULL,
+    client_state                VARCHAR(128) NOT NULL,
+    expiration_date_time        TIMESTAMPTZ  NOT NULL,
+    created_at                  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    last_notification_at        TIMESTAMPTZ
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE VIEW graph_subscriptions_health AS
+SELECT
+    id,
+    resource,
+    expiration_date_time,
+    expiration_date_time - NOW()                     AS expires_in,
+    expiration_date_time <= NOW()                    AS expired,
+    last_notification_at,
+    NOW() - COALESCE(last_notification_at, created_at) AS silent_for
+FROM graph_subscriptions;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP VIEW graph_subscriptions_health;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE graph_subscriptions;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS sync_failures (
+    site_id          VARCHAR(100) NOT NULL,
+    list_id          VARCHAR(40)  NOT NULL,
+    full_sync        BOOLEAN      NOT NULL DEFAULT FALSE,
+    error            TEXT         NOT NULL,
+    attempts         INTEGER      NOT NULL DEFAULT 1,
+    next_attempt_at  TIMESTAMPTZ,
+    first_failed_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    last_failed_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (site_id, list_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+COMMENT ON COLUMN sync_failures.next_attempt_at IS 'NULL once the retry attempts are exhausted';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE sync_failures;
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS sync_runs (
+    id           BIGSERIAL    NOT NULL PRIMARY KEY,
+    site_id      VARCHAR(100) NOT NULL,
+    list_id      VARCHAR(40)  NOT NULL,
+    trigger      VARCHAR(16)  NOT NULL,
+    full_sync    BOOLEAN      NOT NULL,
+    started_at   TIMESTAMPTZ  NOT NULL,
+    finished_at  TIMESTAMPTZ  NOT NULL,
+    inserted     INTEGER      NOT NULL DEFAULT 0,
+    updated      INTEGER      NOT NULL DEFAULT 0,
+    deleted      INTEGER      NOT NULL DEFAULT 0,
+    pages        INTEGER      NOT NULL DEFAULT 0,
+    error        TEXT
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS sync_runs_list_started_at_idx ON sync_runs (site_id, list_id, started_at DESC);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE VIEW sync_runs_freshness AS
+SELECT
+    site_id,
+    list_id,
+    MAX(finished_at)                                   AS last_run_at,
+    MAX(finished_at) FILTER (WHERE error IS NULL)      AS last_success_at,
+    NOW() - MAX(finished_at) FILTER (WHERE error IS NULL) AS staleness,
+    COUNT(*) FILTER (WHERE error IS NOT NULL
+                     AND started_at > NOW() - INTERVAL '1 day') AS failures_last_day
+FROM sync_runs
+GROUP BY site_id, list_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP VIEW sync_runs_freshness;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE sync_runs;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS sync_pending (
+    site_id     VARCHAR(100) NOT NULL,
+    list_id     VARCHAR(40)  NOT NULL,
+    full_sync   BOOLEAN      NOT NULL DEFAULT FALSE,
+    trigger     VARCHAR(16)  NOT NULL,
+    queued_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (site_id, list_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+COMMENT ON TABLE sync_pending IS 'Syncs left unfinished at shutdown, resumed on the next start';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE sync_pending;
+-- +goose StatementEnd
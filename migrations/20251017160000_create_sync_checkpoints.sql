@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS sync_checkpoints (
+    site_id     VARCHAR(100) NOT NULL,
+    list_id     VARCHAR(40)  NOT NULL,
+    next_link   TEXT         NOT NULL,
+    full_sync   BOOLEAN      NOT NULL DEFAULT FALSE,
+    pages       INTEGER      NOT NULL DEFAULT 0,
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (site_id, list_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+COMMENT ON TABLE sync_checkpoints IS 'Next page links of interrupted syncs, removed once the sync completes';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE sync_checkpoints;
+-- +goose StatementEnd
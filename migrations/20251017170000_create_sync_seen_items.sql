@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS sync_seen_items (
+    site_id  VARCHAR(100) NOT NULL,
+    list_id  VARCHAR(40)  NOT NULL,
+    item_id  VARCHAR(40)  NOT NULL,
+    PRIMARY KEY (site_id, list_id, item_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+COMMENT ON TABLE sync_seen_items IS 'Items seen by an unfinished full sync, the list items missing from it are deleted once the sync completes';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE sync_seen_items;
+-- +goose StatementEnd
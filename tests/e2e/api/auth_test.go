@@ -61,13 +61,11 @@ func TestNewGraphHelperInitialization(t *testing.T) {
 			}
 
 			// Initialize GraphHelper
-			ctx := context.Background()
-			graph, err := api.NewGraphHelper(ctx)
+			graph, err := api.NewGraphHelper(nil)
 			require.NoError(t, err, "Failed to initialize GraphHelper")
 			require.NotNil(t, graph, "GraphHelper should be instantiated")
 
 			// Validate that authentication and initialization succeeded
-			assert.NotNil(t, graph.Ctx, "GraphHelper context should be set")
 			assert.NotNil(t, graph.Credential, "GraphHelper credential should be initialized")
 			assert.NotNil(t, graph.Adapter, "GraphHelper adapter should be initialized")
 			assert.NotNil(t, graph.Client, "GraphHelper client should be initialized")
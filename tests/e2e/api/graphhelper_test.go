@@ -3,7 +3,6 @@
 package api_test
 
 import (
-	"context"
 	"testing"
 
 	"microsoft-apps-exporter/internal/api"
@@ -14,8 +13,7 @@ import (
 
 // TestGraphHelper_Initialize validates that GraphHelper initializes properly
 func TestGraphHelper_Initialize(t *testing.T) {
-	ctx := context.Background()
-	graphHelper, err := api.NewGraphHelper(ctx)
+	graphHelper, err := api.NewGraphHelper(nil)
 
 	assert.NoError(t, err, "GraphHelper should initialize without errors")
 	require.NotNil(t, graphHelper, "GraphHelper should not be nil")
@@ -16,7 +16,7 @@ import (
 func TestRequestList_Success(t *testing.T) {
 	setupProdResourcesYaml()
 
-	graphHelper, err := api.NewGraphHelper(context.Background())
+	graphHelper, err := api.NewGraphHelper(nil)
 	require.NoError(t, err, "Failed to initialize GraphHelper")
 
 	config := configuration.GetConfig()
@@ -30,7 +30,7 @@ func TestRequestList_Success(t *testing.T) {
 	list := config.Sharepoint.Lists[0]
 	siteID, listID := list.SiteID, list.ListID
 
-	listable, err := graphHelper.RequestList(siteID, listID)
+	listable, err := graphHelper.RequestList(context.Background(), siteID, listID)
 
 	assert.NoError(t, err, "Fetching SharePoint list should succeed")
 	assert.NotNil(t, listable, "List should not be nil")
@@ -40,7 +40,7 @@ func TestRequestList_Success(t *testing.T) {
 func TestRequestListItemsWithDelta(t *testing.T) {
 	setupProdResourcesYaml()
 
-	graphHelper, err := api.NewGraphHelper(context.Background())
+	graphHelper, err := api.NewGraphHelper(nil)
 	require.NoError(t, err, "Failed to initialize GraphHelper")
 
 	config := configuration.GetConfig()
@@ -56,10 +56,11 @@ func TestRequestListItemsWithDelta(t *testing.T) {
 
 	var top int32 = 3000
 	options := api.NewListItemsWithDeltaOptions(nil, &top)
-	_, items, err := graphHelper.RequestListItemsWithDelta(siteID, listID, nil, options)
+	_, items, pages, err := graphHelper.RequestListItemsWithDelta(context.Background(), siteID, listID, nil, options)
 
 	assert.NoError(t, err, "Fetching list items should succeed")
 	assert.NotNil(t, items, "Items should not be nil")
+	assert.Greater(t, pages, 0, "At least one page should be requested")
 	assert.Equal(t, len(items), int(top), "Should return requested amount in options (if the resource actualy has this amount)")
 	// assert.NotNil(t, deltaLink, "Delta link should not be nil")
 }
@@ -68,7 +69,7 @@ func TestRequestListItemsWithDelta(t *testing.T) {
 func TestRequestListItems_WithDelta(t *testing.T) {
 	setupProdResourcesYaml()
 
-	graphHelper, err := api.NewGraphHelper(context.Background())
+	graphHelper, err := api.NewGraphHelper(nil)
 	require.NoError(t, err, "Failed to initialize GraphHelper")
 
 	config := configuration.GetConfig()
@@ -83,12 +84,12 @@ func TestRequestListItems_WithDelta(t *testing.T) {
 	options := api.NewListItemsWithDeltaOptions(nil, &top)
 
 	// First request to get a valid delta link
-	deltaLink, _, err := graphHelper.RequestListItemsWithDelta(siteID, listID, nil, options)
+	deltaLink, _, _, err := graphHelper.RequestListItemsWithDelta(context.Background(), siteID, listID, nil, options)
 	assert.NoError(t, err, "Initial request should succeed")
 	assert.NotNil(t, deltaLink, "Initial delta link should not be nil")
 
 	// Second request using delta link
-	newDeltaLink, items, err := graphHelper.RequestListItemsWithDelta(siteID, listID, deltaLink, options)
+	newDeltaLink, items, _, err := graphHelper.RequestListItemsWithDelta(context.Background(), siteID, listID, deltaLink, options)
 
 	assert.NoError(t, err, "Fetching incremental updates should succeed")
 	assert.NotNil(t, items, "Incremental update items should not be nil")
@@ -99,7 +100,7 @@ func TestRequestListItems_WithDelta(t *testing.T) {
 func TestParseListItemResponse(t *testing.T) {
 	setupProdResourcesYaml()
 
-	graphHelper, err := api.NewGraphHelper(context.Background())
+	graphHelper, err := api.NewGraphHelper(nil)
 	require.NoError(t, err, "Failed to initialize GraphHelper")
 
 	config := configuration.GetConfig()
@@ -116,8 +117,9 @@ func TestParseListItemResponse(t *testing.T) {
 	var top int32 = 10
 	options := api.NewListItemsWithDeltaOptions(nil, &top)
 
-	_, items, err := graphHelper.RequestListItemsWithDelta(siteID, listID, nil, options)
+	_, items, pages, err := graphHelper.RequestListItemsWithDelta(context.Background(), siteID, listID, nil, options)
 	assert.NoError(t, err, "Fetching list items should succeed")
+	assert.Equal(t, 1, pages, "Requested amount should fit a single page")
 	assert.Greater(t, len(items), 0, "Should return at least one item")
 	assert.Equal(t, len(items), int(top), "Should return requested amount in options (if the resource actualy has this amount)")
 
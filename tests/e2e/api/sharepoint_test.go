@@ -4,6 +4,7 @@ package api_test
 
 import (
 	"context"
+	"errors"
 	"microsoft-apps-exporter/internal/api"
 	"microsoft-apps-exporter/internal/configuration"
 	"testing"
@@ -15,7 +16,7 @@ import (
 func TestGetList(t *testing.T) {
 	setupProdResourcesYaml()
 
-	graphHelper, err := api.NewGraphHelper(context.Background())
+	graphHelper, err := api.NewGraphHelper(nil)
 	require.NoError(t, err, "Failed to initialize GraphHelper")
 
 	config := configuration.GetConfig()
@@ -29,7 +30,7 @@ func TestGetList(t *testing.T) {
 	list := config.Sharepoint.Lists[0]
 	siteID, listID := list.SiteID, list.ListID
 
-	metadata, err := graphHelper.GetList(siteID, listID)
+	metadata, err := graphHelper.GetList(context.Background(), siteID, listID)
 
 	assert.NoError(t, err, "Fetching list metadata should succeed")
 	assert.Len(t, metadata, 1, "Expected exactly one list metadata object")
@@ -42,10 +43,10 @@ func TestGetList(t *testing.T) {
 	assert.NotEmpty(t, md.DisplayName, "Display name should not be empty")
 }
 
-func TestGetListItemsWithDelta(t *testing.T) {
+func TestForEachListItemsPage(t *testing.T) {
 	setupProdResourcesYaml()
 
-	graphHelper, err := api.NewGraphHelper(context.Background())
+	graphHelper, err := api.NewGraphHelper(nil)
 	require.NoError(t, err, "Failed to initialize GraphHelper")
 
 	config := configuration.GetConfig()
@@ -59,27 +60,37 @@ func TestGetListItemsWithDelta(t *testing.T) {
 	list := config.Sharepoint.Lists[0]
 	siteID, listID := list.SiteID, list.ListID
 
-	var top int32 = 10
+	var top int32 = 5
 	options := api.NewListItemsWithDeltaOptions(nil, &top)
 
-	// First call to retrieve data and initial delta
-	deltaLink, items, err := graphHelper.GetListItemsWithDelta(siteID, listID, nil, options)
-	assert.NoError(t, err, "Fetching list items with delta should succeed")
-	assert.Nil(t, deltaLink, "Delta link should be nil")
-	assert.NotNil(t, items, "List items should not be nil")
-	assert.Greater(t, len(*items), 0, "Expected at least one list item")
-
-	item := (*items)[0]
+	// Stop after the first page and resume from its next link
+	var firstPage api.ListItemsPage
+	errStop := errors.New("stop")
+	pages, err := graphHelper.ForEachListItemsPage(context.Background(), siteID, listID, nil, options, func(page api.ListItemsPage) error {
+		firstPage = page
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop, "The callback error should stop the iteration")
+	assert.Equal(t, 1, pages, "Only the first page should be requested")
+	require.NotEmpty(t, firstPage.Items, "Expected at least one list item")
+
+	item := firstPage.Items[0]
 	assert.NotEmpty(t, item.Metadata.ID, "Item ID should not be empty")
 	assert.Equal(t, listID, item.Metadata.ListID, "ListID in metadata should match")
 	assert.Equal(t, siteID, item.Metadata.SiteID, "SiteID in metadata should match")
 	assert.NotEmpty(t, item.Metadata.ETag, "ETag should not be empty")
 	assert.Greater(t, len(item.MappedFields), 0, "Item fields should not be empty")
-	/*
-		// Second call with delta (usually returns 0 unless items changed recently)
-		newDeltaLink, newItems, err := graphHelper.GetListItemsWithDelta(siteID, listID, deltaLink, nil)
-		assert.NoError(t, err, "Fetching with delta link should not fail")
-		assert.NotNil(t, newDeltaLink, "New delta link should be returned")
-		assert.NotNil(t, newItems, "Items should not be nil")
-	*/
+
+	if firstPage.NextLink == nil {
+		t.Skip("The list fits a single page")
+	}
+
+	pages, err = graphHelper.ForEachListItemsPage(context.Background(), siteID, listID, firstPage.NextLink, options, func(page api.ListItemsPage) error {
+		for _, item := range page.Items {
+			assert.Equal(t, listID, item.Metadata.ListID, "ListID in metadata should match")
+		}
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, 1, pages, "Resuming from the next link should request the following page")
 }
@@ -28,7 +28,7 @@ func setupTest(t *testing.T) (context.Context, *api.GraphHelper, *webhook.Webhoo
 	ctx := context.Background()
 
 	// Initialize Graph client
-	graph, err := api.NewGraphHelper(ctx)
+	graph, err := api.NewGraphHelper(nil)
 	require.NoError(t, err, "failed to initialize GraphHelper")
 
 	// Start webhook server
@@ -37,10 +37,10 @@ func setupTest(t *testing.T) (context.Context, *api.GraphHelper, *webhook.Webhoo
 	require.NoError(t, err, "failed to start webhook server")
 
 	t.Cleanup(func() {
-		subscriptions, _ := graph.GetSubscriptions()
+		subscriptions, _ := graph.GetSubscriptions(ctx)
 
 		for _, sub := range subscriptions {
-			if err := graph.DeleteSubscription(*sub.GetId()); err != nil {
+			if err := graph.DeleteSubscription(ctx, *sub.GetId()); err != nil {
 				require.NoError(t, err, "DeleteSubscription failed")
 			}
 		}
@@ -51,7 +51,7 @@ func setupTest(t *testing.T) (context.Context, *api.GraphHelper, *webhook.Webhoo
 }
 
 func TestEnsureResourcesSubscriptions(t *testing.T) {
-	_, graph, _ := setupTest(t)
+	ctx, graph, _ := setupTest(t)
 
 	config := configuration.GetConfig()
 	if config.Sharepoint == nil {
@@ -59,32 +59,32 @@ func TestEnsureResourcesSubscriptions(t *testing.T) {
 	}
 
 	expectedCount := len(config.Sharepoint.Lists)
-	subscriptions, err := graph.GetSubscriptions()
+	subscriptions, err := graph.GetSubscriptions(ctx)
 
 	for _, sub := range subscriptions {
-		if err := graph.DeleteSubscription(*sub.GetId()); err != nil {
+		if err := graph.DeleteSubscription(ctx, *sub.GetId()); err != nil {
 			require.NoError(t, err, "DeleteSubscription failed")
 		}
 	}
 
 	// Subscriptions dont exist case
-	subscriptions, err = graph.EnsureResourcesSubscriptions()
+	subscriptions, err = graph.EnsureResourcesSubscriptions(ctx)
 	require.NoError(t, err, "EnsureResourcesSubscriptions failed")
 	assert.Equal(t, expectedCount, len(subscriptions), "expected same number of subscriptions as resources")
 
 	//  Subscriptions exist case
-	subscriptions, err = graph.EnsureResourcesSubscriptions()
+	subscriptions, err = graph.EnsureResourcesSubscriptions(ctx)
 	require.NoError(t, err, "EnsureResourcesSubscriptions failed")
 	assert.Equal(t, expectedCount, len(subscriptions), "expected same number of subscriptions as resources")
 
 }
 
 func TestCreateResourceSubscription(t *testing.T) {
-	_, graph, _ := setupTest(t)
+	ctx, graph, _ := setupTest(t)
 
-	subscriptions, _ := graph.GetSubscriptions()
+	subscriptions, _ := graph.GetSubscriptions(ctx)
 	for _, sub := range subscriptions {
-		if err := graph.DeleteSubscription(*sub.GetId()); err != nil {
+		if err := graph.DeleteSubscription(ctx, *sub.GetId()); err != nil {
 			require.NoError(t, err, "DeleteSubscription failed")
 		}
 	}
@@ -97,11 +97,11 @@ func TestCreateResourceSubscription(t *testing.T) {
 	for _, list := range config.Sharepoint.Lists {
 		resource := models.GenerateSharepointResourceString(list.SiteID, list.ListID)
 
-		sub, err := graph.CreateResourceSubscription(resource, api.WebhookSubscriptionEndpoint)
+		sub, err := graph.CreateResourceSubscription(ctx, resource, api.WebhookSubscriptionEndpoint)
 		require.NoError(t, err, "CreateResourceSubscription failed")
 		assert.Equal(t, resource, *sub.GetResource())
 
-		subscriptions, _ := graph.GetSubscriptions()
+		subscriptions, _ := graph.GetSubscriptions(ctx)
 		found := false
 		for _, existingSub := range subscriptions {
 			if *existingSub.GetId() == *sub.GetId() {
@@ -114,44 +114,44 @@ func TestCreateResourceSubscription(t *testing.T) {
 }
 
 func TestGetSubscriptions(t *testing.T) {
-	_, graph, _ := setupTest(t)
+	ctx, graph, _ := setupTest(t)
 
-	expectedSubscriptions, err := graph.EnsureResourcesSubscriptions()
+	expectedSubscriptions, err := graph.EnsureResourcesSubscriptions(ctx)
 	require.NoError(t, err, "EnsureResourcesSubscriptions failed")
 
-	subscriptions, err := graph.GetSubscriptions()
+	subscriptions, err := graph.GetSubscriptions(ctx)
 	require.NoError(t, err, "GetSubscriptions failed")
 	assert.Equal(t, len(expectedSubscriptions), len(subscriptions), "expected same number of subscriptions as resources")
 }
 
 func TestUpdateSubscription(t *testing.T) {
-	_, graph, _ := setupTest(t)
+	ctx, graph, _ := setupTest(t)
 
-	expectedSubscriptions, err := graph.EnsureResourcesSubscriptions()
+	expectedSubscriptions, err := graph.EnsureResourcesSubscriptions(ctx)
 	require.NoError(t, err, "EnsureResourcesSubscriptions failed")
 
 	for _, sub := range expectedSubscriptions {
-		updated, err := graph.UpdateSubscription(*sub.GetId())
+		updated, err := graph.UpdateSubscription(ctx, *sub.GetId())
 		require.NoError(t, err, "UpdateSubscription failed")
 
-		expectedExpiry := time.Now().Add(api.SubscriptionUpdateExpiry)
+		expectedExpiry := time.Now().Add(configuration.GetConfig().SUBSCRIPTION_UPDATE_EXPIRY)
 		actualExpiry := *updated.GetExpirationDateTime()
 		assert.WithinDuration(t, expectedExpiry, actualExpiry, 5*time.Minute)
 	}
 }
 
 func TestDeleteSubscription(t *testing.T) {
-	_, graph, _ := setupTest(t)
+	ctx, graph, _ := setupTest(t)
 
-	expectedSubscriptions, err := graph.EnsureResourcesSubscriptions()
+	expectedSubscriptions, err := graph.EnsureResourcesSubscriptions(ctx)
 	require.NoError(t, err, "EnsureResourcesSubscriptions failed")
 
 	for _, sub := range expectedSubscriptions {
-		err = graph.DeleteSubscription(*sub.GetId())
+		err = graph.DeleteSubscription(ctx, *sub.GetId())
 		require.NoError(t, err, "DeleteSubscription failed")
 
 		// Validate that the subscription was deleted
-		subscriptions, err := graph.GetSubscriptions()
+		subscriptions, err := graph.GetSubscriptions(ctx)
 		require.NoError(t, err, "GetSubscriptions failed")
 
 		for _, existingSub := range subscriptions {
@@ -161,13 +161,13 @@ func TestDeleteSubscription(t *testing.T) {
 }
 
 // func TestReauthorizeSubscription(t *testing.T) {
-// 	_, graph, _ := setupTest(t)
+// 	ctx, graph, _ := setupTest(t)
 
-// 	expectedSubscriptions, err := graph.EnsureResourcesSubscriptions()
+// 	expectedSubscriptions, err := graph.EnsureResourcesSubscriptions(ctx)
 // 	require.NoError(t, err, "EnsureResourcesSubscriptions failed")
 
 // 	for _, sub := range expectedSubscriptions {
-// 		err = graph.ReauthorizeSubscription(*sub.GetId())
+// 		err = graph.ReauthorizeSubscription(ctx, *sub.GetId())
 // 		require.NoError(t, err, "ReauthorizeSubscription failed")
 // 	}
 // }
@@ -4,6 +4,7 @@ package database_test
 
 /*
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"math"
@@ -32,7 +33,7 @@ func TestDatabaseConnectionAndPing(t *testing.T) {
 	setupEnv()
 	setupProdResourcesYaml()
 
-	db, err := database.NewDatabase()
+	db, err := database.NewDatabase(context.Background())
 	require.NoError(t, err, "Database connection should succeed")
 	require.NotNil(t, db.Connection, "DB connection object must not be nil")
 
@@ -52,7 +53,7 @@ func TestExpectedSchemaPresent(t *testing.T) {
 		t.Fatal("Sharepoint resource with at least one list must be configured")
 	}
 
-	db, err := database.NewDatabase()
+	db, err := database.NewDatabase(context.Background())
 	require.NoError(t, err)
 	defer db.Close()
 
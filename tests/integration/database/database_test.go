@@ -3,6 +3,7 @@
 package database_test
 
 import (
+	"context"
 	"log/slog"
 	"math"
 	"microsoft-apps-exporter/internal/database"
@@ -15,7 +16,7 @@ import (
 func TestNewDatabase_ConnectionSuccess(t *testing.T) {
 	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
 
-	db, err := database.NewDatabase()
+	db, err := database.NewDatabase(context.Background())
 	assert.NoError(t, err, "Expected no error when initializing the database")
 	require.NotNil(t, db, "Database instance should not be nil")
 	assert.NotNil(t, db.Connection, "Database connection should not be nil")
@@ -29,7 +30,7 @@ func TestNewDatabase_ConnectionSuccess(t *testing.T) {
 func TestDatabase_Close(t *testing.T) {
 	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
 
-	db, err := database.NewDatabase()
+	db, err := database.NewDatabase(context.Background())
 	assert.NoError(t, err, "Expected no error when initializing the database")
 	require.NotNil(t, db, "Database instance should not be nil")
 
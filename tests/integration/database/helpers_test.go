@@ -0,0 +1,56 @@
+//go:build testing && integration
+
+package database_test
+
+import (
+	"context"
+	"microsoft-apps-exporter/internal/database"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// migrationsDir is the directory of the goose migrations, relative to the package of the tests.
+const migrationsDir = "../../../migrations"
+
+// setupMigratedDatabase connects to the test database and creates the schema of the migrations as temporary objects.
+// Temporary tables are bound to the session creating them, so the pool is pinned to a single connection beforehand.
+func setupMigratedDatabase(t *testing.T) *database.Database {
+	db, err := database.NewDatabase(context.Background())
+	require.NoError(t, err, "Failed to connect to the test database")
+	require.NotNil(t, db, "Database instance should not be nil")
+
+	db.Connection.SetMaxOpenConns(1)
+	db.Connection.SetMaxIdleConns(1)
+	db.Connection.SetConnMaxLifetime(0)
+
+	paths, err := filepath.Glob(filepath.Join(migrationsDir, "*.sql"))
+	require.NoError(t, err, "Failed to list migrations")
+	require.NotEmpty(t, paths, "Migrations should be found in %s", migrationsDir)
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		require.NoError(t, err, "Failed to read migration %s", path)
+
+		_, err = db.Connection.ExecContext(context.Background(), temporaryMigration(string(content)))
+		require.NoError(t, err, "Failed to apply migration %s", filepath.Base(path))
+	}
+
+	return db
+}
+
+// temporaryMigration returns the statements of the goose Up section, creating temporary tables and views
+// so the tests do not touch the schema of the test database.
+func temporaryMigration(content string) string {
+	up, _, _ := strings.Cut(content, "-- +goose Down")
+	up = strings.ReplaceAll(up, "CREATE TABLE", "CREATE TEMP TABLE")
+	return strings.ReplaceAll(up, "CREATE OR REPLACE VIEW", "CREATE OR REPLACE TEMP VIEW")
+}
+
+// teardownTestDatabase cleans up the test database.
+func teardownTestDatabase(db *database.Database) {
+	db.Connection.Close()
+}
@@ -3,12 +3,14 @@
 package database_test
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"log/slog"
 	"math"
 	"microsoft-apps-exporter/internal/database"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -19,13 +21,13 @@ import (
 func TestTransactionCommit(t *testing.T) {
 	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
 
-	db, err := database.NewDatabase()
+	db, err := database.NewDatabase(context.Background())
 	assert.NoError(t, err, "Database should initialize correctly")
 	require.NotNil(t, db, "Database failed to initialize")
 	defer db.Close()
 
 	// Begin transaction and commit
-	err = db.WithTransaction(func(tx *sql.Tx) error {
+	err = db.WithTransaction(context.Background(), func(ctx context.Context, tx *sql.Tx) error {
 		_, execErr := tx.Exec("CREATE TABLE IF NOT EXISTS commit_test_table (id SERIAL PRIMARY KEY, value TEXT)")
 		if execErr != nil {
 			return execErr
@@ -50,7 +52,7 @@ func TestTransactionCommit(t *testing.T) {
 func TestTransactionRollback(t *testing.T) {
 	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
 
-	db, err := database.NewDatabase()
+	db, err := database.NewDatabase(context.Background())
 	require.NotNil(t, db, "Database failed to initialize")
 	assert.NoError(t, err, "Database should initialize correctly")
 	defer db.Close()
@@ -59,7 +61,7 @@ func TestTransactionRollback(t *testing.T) {
 	assert.NoError(t, err, "Table creation should not fail")
 
 	// Start transaction and attempt rollback
-	err = db.WithTransaction(func(tx *sql.Tx) error {
+	err = db.WithTransaction(context.Background(), func(ctx context.Context, tx *sql.Tx) error {
 		_, execErr := tx.Exec("INSERT INTO rollback_test_table (value) VALUES ('rollback_test')")
 		if execErr != nil {
 			return execErr
@@ -83,12 +85,12 @@ func TestTransactionRollback(t *testing.T) {
 func TestRollbackFailure(t *testing.T) {
 	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
 
-	db, err := database.NewDatabase()
+	db, err := database.NewDatabase(context.Background())
 	require.NotNil(t, db, "Database failed to initialize")
 	assert.NoError(t, err, "Database should initialize correctly")
 	defer db.Close()
 
-	err = db.WithTransaction(func(tx *sql.Tx) error {
+	err = db.WithTransaction(context.Background(), func(ctx context.Context, tx *sql.Tx) error {
 		tx.Rollback()                            // First rollback happens here
 		return errors.New("some rollback error") // Forces rollback again
 	})
@@ -100,7 +102,7 @@ func TestRollbackFailure(t *testing.T) {
 func TestTransactionPanicHandling(t *testing.T) {
 	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
 
-	db, err := database.NewDatabase()
+	db, err := database.NewDatabase(context.Background())
 	require.NotNil(t, db, "Database failed to initialize")
 	assert.NoError(t, err, "Database should initialize correctly")
 	defer db.Close()
@@ -111,7 +113,7 @@ func TestTransactionPanicHandling(t *testing.T) {
 		}
 	}()
 
-	err = db.WithTransaction(func(tx *sql.Tx) error {
+	err = db.WithTransaction(context.Background(), func(ctx context.Context, tx *sql.Tx) error {
 		_, execErr := tx.Exec("CREATE TABLE IF NOT EXISTS panic_test_table (id SERIAL PRIMARY KEY, value TEXT)")
 		if execErr != nil {
 			return execErr
@@ -133,3 +135,19 @@ func TestTransactionPanicHandling(t *testing.T) {
 	// Cleanup test data
 	_, _ = db.Connection.Exec("DELETE FROM panic_test_table")
 }
+
+// TestTransactionTimeout ensures a transaction exceeding the query timeout is aborted and rolled back.
+func TestTransactionTimeout(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	db, err := database.NewDatabase(context.Background())
+	require.NoError(t, err, "Database should initialize correctly")
+	defer db.Close()
+
+	db.QueryTimeout = 50 * time.Millisecond
+	err = db.WithTransaction(context.Background(), func(ctx context.Context, tx *sql.Tx) error {
+		_, execErr := tx.ExecContext(ctx, "SELECT pg_sleep(1)")
+		return execErr
+	})
+	assert.Error(t, err, "Transaction exceeding the timeout should fail")
+}
@@ -0,0 +1,45 @@
+//go:build testing && integration
+
+package database_test
+
+import (
+	"context"
+	"microsoft-apps-exporter/internal/schema"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTableColumns tests the columns of a table are read from information_schema once the DDL is applied.
+func TestTableColumns(t *testing.T) {
+	db := setupMigratedDatabase(t)
+	defer teardownTestDatabase(db)
+
+	exists, err := db.TableExists(context.Background(), "schema_items")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	columns, err := db.GetTableColumns(context.Background(), "schema_items")
+	require.NoError(t, err)
+	assert.Empty(t, columns, "A missing table should have no columns")
+
+	require.NoError(t, db.ApplySchema(context.Background(), `
+		CREATE TEMP TABLE schema_items (
+			id    TEXT NOT NULL PRIMARY KEY,
+			score REAL
+		);
+	`))
+	require.NoError(t, db.ApplySchema(context.Background(),
+		schema.AddColumnStatement("schema_items", schema.Column{Name: "nickname", Type: "VARCHAR(20)"})))
+
+	exists, err = db.TableExists(context.Background(), "schema_items")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	columns, err = db.GetTableColumns(context.Background(), "schema_items")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"id": "text", "score": "real", "nickname": "character varying"}, columns)
+
+	assert.Error(t, db.ApplySchema(context.Background(), "ALTER TABLE missing_items ADD COLUMN score REAL;"))
+}
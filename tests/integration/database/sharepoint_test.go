@@ -13,28 +13,12 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-// setupTestDatabase initializes the test database and creates the required tables.
+// setupTestDatabase initializes the test database with the migrated tables and a table for list items.
 func setupTestDatabase(t *testing.T) *database.Database {
-	// Connect to the test database
-	db, err := database.NewDatabase()
-	require.NoError(t, err, "Failed to connect to the test database")
-	require.NotNil(t, db, "Database instance should not be nil")
-
-	// Create the sharepoint_lists table
-	_, err = db.Connection.ExecContext(context.Background(), `
-		CREATE TEMP TABLE sharepoint_lists (
-			id TEXT PRIMARY KEY,
-			site_id TEXT,
-			etag TEXT,
-			name TEXT,
-			display_name TEXT,
-			delta_link TEXT
-		);
-	`)
-	require.NoError(t, err, "Failed to create sharepoint_lists table")
+	db := setupMigratedDatabase(t)
 
 	// Create a dynamic table for list items
-	_, err = db.Connection.ExecContext(context.Background(), `
+	_, err := db.Connection.ExecContext(context.Background(), `
 		CREATE TEMP TABLE list_items (
 			id TEXT PRIMARY KEY,
 			list_id TEXT,
@@ -49,11 +33,6 @@ func setupTestDatabase(t *testing.T) *database.Database {
 	return db
 }
 
-// teardownTestDatabase cleans up the test database.
-func teardownTestDatabase(db *database.Database) {
-	db.Connection.Close()
-}
-
 /*
 Lists
 */
@@ -71,7 +50,7 @@ func TestGetList(t *testing.T) {
 	require.NoError(t, err, "Failed to insert test data")
 
 	// Test GetList
-	lists, err := db.GetList("list-001")
+	lists, err := db.GetList(context.Background(), "list-001")
 	assert.NoError(t, err, "GetList should not return an error")
 	assert.Len(t, lists, 1, "Expected one list to be returned")
 
@@ -112,7 +91,7 @@ func TestInsertLists(t *testing.T) {
 	}
 
 	// Test InsertLists
-	err := db.InsertLists(&lists)
+	err := db.InsertLists(context.Background(), &lists)
 	assert.NoError(t, err, "InsertLists should not return an error")
 
 	// Verify data was inserted
@@ -143,7 +122,7 @@ func TestUpdateListIgnoreDelta(t *testing.T) {
 		DisplayName: "Updated Display Name",
 		DeltaLink:   stringPtr("delta-link-001"),
 	}
-	err = db.UpdateListIgnoreDelta(updatedMetadata)
+	err = db.UpdateListIgnoreDelta(context.Background(), updatedMetadata)
 	assert.NoError(t, err, "UpdateListIgnoreDelta should not return an error")
 
 	// Verify data was updated
@@ -177,7 +156,7 @@ func TestDeleteList(t *testing.T) {
 	require.NoError(t, err, "Failed to insert test data")
 
 	// Test DeleteList
-	err = db.DeleteList("list-001")
+	err = db.DeleteList(context.Background(), "list-001")
 	assert.NoError(t, err, "DeleteList should not return an error")
 
 	// Verify data was deleted
@@ -200,37 +179,11 @@ func TestGetDeltaLink(t *testing.T) {
 	require.NoError(t, err, "Failed to insert test data")
 
 	// Test GetDeltaLink
-	deltaLink, err := db.GetDeltaLink("list-001")
+	deltaLink, err := db.GetDeltaLink(context.Background(), "list-001")
 	assert.NoError(t, err, "GetDeltaLink should not return an error")
 	assert.Equal(t, "delta-link-001", *deltaLink, "Delta link should match expected")
 }
 
-// TestSaveDeltaLink tests the SaveDeltaLink function.
-func TestSaveDeltaLink(t *testing.T) {
-	db := setupTestDatabase(t)
-	defer teardownTestDatabase(db)
-
-	// Insert test data
-	_, err := db.Connection.ExecContext(context.Background(), `
-		INSERT INTO sharepoint_lists (id, site_id, etag, name, display_name, delta_link)
-		VALUES ('list-001', 'site-001', 'etag-001', 'Test List', 'Test Display Name', NULL);
-	`)
-	require.NoError(t, err, "Failed to insert test data")
-
-	// Test SaveDeltaLink
-	err = db.SaveDeltaLink("list-001", "delta-link-001")
-	assert.NoError(t, err, "SaveDeltaLink should not return an error")
-
-	// Verify delta link was saved
-	var deltaLink sql.NullString
-	err = db.Connection.QueryRowContext(context.Background(), `
-		SELECT delta_link FROM sharepoint_lists WHERE id = 'list-001';
-	`).Scan(&deltaLink)
-	assert.NoError(t, err, "Failed to query delta link")
-	assert.True(t, deltaLink.Valid, "Delta link should not be NULL")
-	assert.Equal(t, "delta-link-001", deltaLink.String, "Delta link should match expected")
-}
-
 // TestDeleteDeltaLink tests the DeleteDeltaLink function.
 func TestDeleteDeltaLink(t *testing.T) {
 	db := setupTestDatabase(t)
@@ -244,7 +197,7 @@ func TestDeleteDeltaLink(t *testing.T) {
 	require.NoError(t, err, "Failed to insert test data")
 
 	// Test DeleteDeltaLink
-	err = db.DeleteDeltaLink("list-001")
+	err = db.DeleteDeltaLink(context.Background(), "list-001")
 	assert.NoError(t, err, "DeleteDeltaLink should not return an error")
 
 	// Verify delta link was deleted
@@ -265,171 +218,315 @@ func stringPtr(s string) *string {
 List Items
 */
 
-// TestGetListItems tests the GetListItems function.
-func TestGetListItems(t *testing.T) {
+// TestWriteListItems tests the items are inserted or updated when they already exist, so replaying them does not fail.
+func TestWriteListItems(t *testing.T) {
+	db := setupTestDatabase(t)
+	defer teardownTestDatabase(db)
+
+	columnsMap := map[string]string{
+		"field1": "field1",
+		"field2": "field2",
+	}
+	listItems := []models.ListItem{
+		{
+			Metadata:     models.ListItemMetadata{ID: "item-001", ListID: "list-001", SiteID: "site-001", ETag: "etag-001"},
+			MappedFields: map[string]interface{}{"field1": "Test Item 1", "field2": 42},
+		},
+		{
+			Metadata:     models.ListItemMetadata{ID: "item-002", ListID: "list-001", SiteID: "site-001", ETag: "etag-002"},
+			MappedFields: map[string]interface{}{"field1": "Test Item 2", "field2": 99},
+		},
+	}
+
+	write := func() models.ChangeCounts {
+		var counts models.ChangeCounts
+		err := db.WithTransaction(context.Background(), func(ctx context.Context, tx *sql.Tx) (err error) {
+			counts, err = db.WriteListItems(ctx, tx, "list_items", columnsMap, listItems)
+			return err
+		})
+		require.NoError(t, err, "WriteListItems should not return an error")
+		return counts
+	}
+
+	assert.Equal(t, models.ChangeCounts{Inserted: 2}, write())
+
+	listItems[0].Metadata.ETag = "etag-003"
+	listItems[0].MappedFields["field1"] = "Updated Item 1"
+	assert.Equal(t, models.ChangeCounts{Updated: 2}, write(), "Existing items should be updated")
+
+	var etag, field1 string
+	var field2 int
+	err := db.Connection.QueryRowContext(context.Background(), `
+		SELECT etag, field1, field2 FROM list_items WHERE id = 'item-001';
+	`).Scan(&etag, &field1, &field2)
+	require.NoError(t, err, "Failed to query updated data")
+	assert.Equal(t, "etag-003", etag, "etag should be updated")
+	assert.Equal(t, "Updated Item 1", field1, "field1 should be updated")
+	assert.Equal(t, 42, field2, "field2 should be kept")
+}
+
+// TestRemoveListItems tests only the existing items count as deleted.
+func TestRemoveListItems(t *testing.T) {
 	db := setupTestDatabase(t)
 	defer teardownTestDatabase(db)
 
 	// Insert test data
 	_, err := db.Connection.ExecContext(context.Background(), `
 		INSERT INTO list_items (id, list_id, site_id, etag, field1, field2)
-		VALUES 
-			('item-001', 'list-001', 'site-001', 'etag-001', 'Test Item 1', 42),
+		VALUES ('item-001', 'list-001', 'site-001', 'etag-001', 'Test Item 1', 42),
 			('item-002', 'list-001', 'site-001', 'etag-002', 'Test Item 2', 99);
 	`)
 	require.NoError(t, err, "Failed to insert test data")
 
-	// Test GetListItems
-	listItems, err := db.GetListItems("list_items", "site-001", "list-001")
-	assert.NoError(t, err, "GetListItems should not return an error")
-	assert.Len(t, *listItems, 2, "Expected 2 list items to be returned")
-
-	// Verify the first list item
-	expectedItem1 := models.ListItem{
-		Metadata: models.ListItemMetadata{
-			ID:     "item-001",
-			ListID: "list-001",
-			SiteID: "site-001",
-			ETag:   "etag-001",
-		},
-		MappedFields: map[string]interface{}{
-			"field1": "Test Item 1",
-			"field2": float64(42), // JSON numbers are unmarshaled as float64
-		},
-	}
-	assert.Equal(t, expectedItem1, (*listItems)[0], "First list item should match expected")
-
-	// Verify the second list item
-	expectedItem2 := models.ListItem{
-		Metadata: models.ListItemMetadata{
-			ID:     "item-002",
-			ListID: "list-001",
-			SiteID: "site-001",
-			ETag:   "etag-002",
-		},
-		MappedFields: map[string]interface{}{
-			"field1": "Test Item 2",
-			"field2": float64(99), // JSON numbers are unmarshaled as float64
-		},
-	}
-	assert.Equal(t, expectedItem2, (*listItems)[1], "Second list item should match expected")
+	var deleted int
+	err = db.WithTransaction(context.Background(), func(ctx context.Context, tx *sql.Tx) (err error) {
+		deleted, err = db.RemoveListItems(ctx, tx, "list_items", []string{"item-001", "item-404"})
+		return err
+	})
+	require.NoError(t, err, "RemoveListItems should not return an error")
+	assert.Equal(t, 1, deleted, "Only existing items should count as deleted")
+
+	// Verify data was deleted
+	var count int
+	err = db.Connection.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM list_items;").Scan(&count)
+	assert.NoError(t, err, "Failed to query row count")
+	assert.Equal(t, 1, count, "Expected 1 row after deletion")
 }
 
-// TestInsertListItems tests the InsertListItems function.
-func TestInsertListItems(t *testing.T) {
+// TestUpsertLists tests the UpsertLists function keeps the delta link of an existing list.
+func TestUpsertLists(t *testing.T) {
 	db := setupTestDatabase(t)
 	defer teardownTestDatabase(db)
 
-	// Test data
-	listItems := []models.ListItem{
-		{
-			Metadata: models.ListItemMetadata{
-				ID:     "item-001",
-				ListID: "list-001",
-				SiteID: "site-001",
-				ETag:   "etag-001",
-			},
-			MappedFields: map[string]interface{}{
-				"field1": "Test Item 1",
-				"field2": 42,
-			},
-		},
-		{
-			Metadata: models.ListItemMetadata{
-				ID:     "item-002",
-				ListID: "list-001",
-				SiteID: "site-001",
-				ETag:   "etag-002",
-			},
-			MappedFields: map[string]interface{}{
-				"field1": "Test Item 2",
-				"field2": 99,
-			},
-		},
-	}
+	_, err := db.Connection.ExecContext(context.Background(), `
+		INSERT INTO sharepoint_lists (id, site_id, etag, name, display_name, delta_link)
+		VALUES ('list-001', 'site-001', 'etag-001', 'Test List', 'Test Display Name', 'delta-link-001');
+	`)
+	require.NoError(t, err, "Failed to insert test data")
+
+	counts, err := db.UpsertLists(context.Background(), []models.ListMetadata{
+		{ID: "list-001", SiteID: "site-001", ETag: "etag-002", Name: "Renamed List", DisplayName: "Renamed Display Name"},
+		{ID: "list-002", SiteID: "site-001", ETag: "etag-003", Name: "New List", DisplayName: "New Display Name"},
+	})
+	require.NoError(t, err, "UpsertLists should not return an error")
+	assert.Equal(t, models.ChangeCounts{Inserted: 1, Updated: 1}, counts)
+
+	lists, err := db.GetList(context.Background(), "list-001")
+	require.NoError(t, err)
+	require.Len(t, lists, 1)
+	assert.Equal(t, "Renamed List", lists[0].Name, "Existing list should be updated")
+	assert.Equal(t, stringPtr("delta-link-001"), lists[0].DeltaLink, "Delta link should be kept")
+}
+
+// TestApplyListItemChanges tests the item changes and the delta link are committed together or not at all.
+func TestApplyListItemChanges(t *testing.T) {
+	db := setupTestDatabase(t)
+	defer teardownTestDatabase(db)
+
+	_, err := db.Connection.ExecContext(context.Background(), `
+		INSERT INTO sharepoint_lists (id, site_id, etag, name, display_name, delta_link)
+		VALUES ('list-001', 'site-001', 'etag-001', 'Test List', 'Test Display Name', 'delta-link-001');
+		INSERT INTO list_items (id, list_id, site_id, etag, field1, field2)
+		VALUES ('item-001', 'list-001', 'site-001', 'etag-001', 'Test Item 1', 42),
+			('item-002', 'list-001', 'site-001', 'etag-002', 'Test Item 2', 99);
+	`)
+	require.NoError(t, err, "Failed to insert test data")
 
-	// Columns mapping
 	columnsMap := map[string]string{
 		"field1": "field1",
 		"field2": "field2",
 	}
+	list := models.ListReference{SiteID: "site-001", ListID: "list-001", DbTableName: "list_items", ColumnsMap: columnsMap}
+	newItem := func(id, etag, field1 string) models.ListItem {
+		return models.ListItem{
+			Metadata:     models.ListItemMetadata{ID: id, ListID: "list-001", SiteID: "site-001", ETag: etag},
+			MappedFields: map[string]interface{}{"field1": field1, "field2": 1},
+		}
+	}
 
-	// Test InsertListItems
-	err := db.InsertListItems("list_items", columnsMap, &listItems)
-	assert.NoError(t, err, "InsertListItems should not return an error")
+	countRows := func() int {
+		var count int
+		err := db.Connection.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM list_items;").Scan(&count)
+		require.NoError(t, err, "Failed to query row count")
+		return count
+	}
 
-	// Verify data was inserted
-	var count int
-	err = db.Connection.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM list_items;").Scan(&count)
-	assert.NoError(t, err, "Failed to query row count")
-	assert.Equal(t, 2, count, "Expected 2 rows to be inserted")
+	// An invalid value fails the whole transaction
+	invalidItem := newItem("item-003", "etag-003", "Invalid Item")
+	invalidItem.MappedFields["field2"] = "not a number"
+	_, err = db.ApplyListItemChanges(context.Background(), list, models.ListItemChanges{
+		Upsert: []models.ListItem{newItem("item-002", "etag-004", "Updated Item 2"), invalidItem},
+		Delete: []string{"item-001"},
+	}, models.SyncProgress{DeltaLink: stringPtr("delta-link-002")})
+	assert.Error(t, err, "Invalid value should fail")
+
+	deltaLink, err := db.GetDeltaLink(context.Background(), "list-001")
+	require.NoError(t, err)
+	assert.Equal(t, "delta-link-001", *deltaLink, "Failed changes should leave the delta link untouched")
+	assert.Equal(t, 2, countRows(), "Failed changes should leave the items untouched")
+
+	counts, err := db.ApplyListItemChanges(context.Background(), list, models.ListItemChanges{
+		Upsert: []models.ListItem{newItem("item-003", "etag-003", "Test Item 3"), newItem("item-002", "etag-004", "Updated Item 2")},
+		Delete: []string{"item-001", "item-404"},
+	}, models.SyncProgress{DeltaLink: stringPtr("delta-link-002")})
+	require.NoError(t, err, "ApplyListItemChanges should not return an error")
+	assert.Equal(t, models.ChangeCounts{Inserted: 1, Updated: 1, Deleted: 1}, counts, "Only existing items should count as deleted")
+
+	deltaLink, err = db.GetDeltaLink(context.Background(), "list-001")
+	require.NoError(t, err)
+	assert.Equal(t, "delta-link-002", *deltaLink, "Delta link should be advanced along with the items")
+	assert.Equal(t, 2, countRows())
+
+	var field1 string
+	err = db.Connection.QueryRowContext(context.Background(), `
+		SELECT field1 FROM list_items WHERE id = 'item-002';
+	`).Scan(&field1)
+	require.NoError(t, err)
+	assert.Equal(t, "Updated Item 2", field1)
 }
 
-// TestUpdateListItem tests the UpdateListItem function.
-func TestUpdateListItem(t *testing.T) {
+// TestApplyListItemChangesBulk tests the changes reaching the bulk threshold are loaded with COPY.
+func TestApplyListItemChangesBulk(t *testing.T) {
 	db := setupTestDatabase(t)
 	defer teardownTestDatabase(db)
 
-	// Insert test data
+	db.BulkThreshold = 2
+
 	_, err := db.Connection.ExecContext(context.Background(), `
+		INSERT INTO sharepoint_lists (id, site_id, etag, name, display_name, delta_link)
+		VALUES ('list-001', 'site-001', 'etag-001', 'Test List', 'Test Display Name', NULL);
 		INSERT INTO list_items (id, list_id, site_id, etag, field1, field2)
-		VALUES ('item-001', 'list-001', 'site-001', 'etag-001', 'Test Item 1', 42);
+		VALUES ('item-001', 'list-001', 'site-001', 'etag-001', 'Test Item 1', 42),
+			('item-002', 'list-001', 'site-001', 'etag-002', 'Test Item 2', 99),
+			('item-003', 'list-001', 'site-001', 'etag-003', 'Test Item 3', 7);
 	`)
 	require.NoError(t, err, "Failed to insert test data")
 
-	// Test UpdateListItem
-	updatedItem := models.ListItem{
-		Metadata: models.ListItemMetadata{
-			ID:     "item-001",
-			ListID: "list-001",
-			SiteID: "site-001",
-			ETag:   "etag-002", // Updated ETag
-		},
-		MappedFields: map[string]interface{}{
-			"field1": "Updated Item 1", // Updated field1
-			"field2": 100,              // Updated field2
-		},
-	}
-
 	columnsMap := map[string]string{
 		"field1": "field1",
 		"field2": "field2",
 	}
+	list := models.ListReference{SiteID: "site-001", ListID: "list-001", DbTableName: "list_items", ColumnsMap: columnsMap}
+	newItem := func(id, etag, field1 string) models.ListItem {
+		return models.ListItem{
+			Metadata:     models.ListItemMetadata{ID: id, ListID: "list-001", SiteID: "site-001", ETag: etag},
+			MappedFields: map[string]interface{}{"field1": field1, "field2": 1},
+		}
+	}
 
-	err = db.UpdateListItem("list_items", columnsMap, updatedItem)
-	assert.NoError(t, err, "UpdateListItem should not return an error")
+	counts, err := db.ApplyListItemChanges(context.Background(), list, models.ListItemChanges{
+		Upsert: []models.ListItem{
+			newItem("item-001", "etag-004", "Stale Item 1"),
+			newItem("item-004", "etag-005", "Test Item 4"),
+			newItem("item-001", "etag-006", "Updated Item 1"), // Repeated, the last occurrence wins
+		},
+		Delete: []string{"item-002", "item-003", "item-404"},
+	}, models.SyncProgress{DeltaLink: stringPtr("delta-link-001")})
+	require.NoError(t, err, "ApplyListItemChanges should not return an error")
+	assert.Equal(t, models.ChangeCounts{Inserted: 1, Updated: 1, Deleted: 2}, counts)
 
-	// Verify data was updated
 	var field1 string
-	var field2 int
 	err = db.Connection.QueryRowContext(context.Background(), `
-		SELECT field1, field2 FROM list_items WHERE id = 'item-001';
-	`).Scan(&field1, &field2)
+		SELECT field1 FROM list_items WHERE id = 'item-001';
+	`).Scan(&field1)
+	require.NoError(t, err)
+	assert.Equal(t, "Updated Item 1", field1)
 
-	assert.NoError(t, err, "Failed to query updated data")
-	assert.Equal(t, "Updated Item 1", field1, "field1 should be updated")
-	assert.Equal(t, 100, field2, "field2 should be updated")
+	var count int
+	err = db.Connection.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM list_items;").Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// The staging table is dropped on commit, so the next bulk load can create it again
+	_, err = db.ApplyListItemChanges(context.Background(), list, models.ListItemChanges{
+		Upsert: []models.ListItem{newItem("item-005", "etag-007", "Test Item 5"), newItem("item-006", "etag-008", "Test Item 6")},
+	}, models.SyncProgress{DeltaLink: stringPtr("delta-link-002")})
+	require.NoError(t, err, "Repeated bulk load should not fail")
 }
 
-// TestDeleteListItem tests the DeleteListItem function.
-func TestDeleteListItem(t *testing.T) {
+// TestApplyListItemChangesPages tests intermediate pages store a checkpoint and the last page of a full sync
+// deletes the items unseen by any page, stores the delta link and removes the checkpoint and the seen items.
+func TestApplyListItemChangesPages(t *testing.T) {
 	db := setupTestDatabase(t)
 	defer teardownTestDatabase(db)
 
-	// Insert test data
 	_, err := db.Connection.ExecContext(context.Background(), `
+		INSERT INTO sharepoint_lists (id, site_id, etag, name, display_name, delta_link)
+		VALUES ('list-001', 'site-001', 'etag-001', 'Test List', 'Test Display Name', NULL);
 		INSERT INTO list_items (id, list_id, site_id, etag, field1, field2)
-		VALUES ('item-001', 'list-001', 'site-001', 'etag-001', 'Test Item 1', 42);
+		VALUES ('item-001', 'list-001', 'site-001', 'etag-001', 'Test Item 1', 42),
+			('item-002', 'list-001', 'site-001', 'etag-002', 'Deleted Item 2', 99),
+			('item-009', 'list-002', 'site-001', 'etag-009', 'Other List Item', 7);
+		INSERT INTO sync_seen_items (site_id, list_id, item_id)
+		VALUES ('site-001', 'list-001', 'item-002');
 	`)
 	require.NoError(t, err, "Failed to insert test data")
 
-	// Test DeleteListItem
-	err = db.DeleteListItem("list_items", "item-001")
-	assert.NoError(t, err, "DeleteListItem should not return an error")
+	columnsMap := map[string]string{
+		"field1": "field1",
+		"field2": "field2",
+	}
+	list := models.ListReference{SiteID: "site-001", ListID: "list-001", DbTableName: "list_items", ColumnsMap: columnsMap}
+	newItem := func(id, etag, field1 string) models.ListItem {
+		return models.ListItem{
+			Metadata:     models.ListItemMetadata{ID: id, ListID: "list-001", SiteID: "site-001", ETag: etag},
+			MappedFields: map[string]interface{}{"field1": field1, "field2": 1},
+		}
+	}
+
+	etags, err := db.GetListItemETags(context.Background(), "list_items", "site-001", "list-001", []string{"item-001", "item-003"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"item-001": "etag-001"}, etags, "Only the existing items of the list should be returned")
+
+	_, err = db.ApplyListItemChanges(context.Background(), list, models.ListItemChanges{
+		Upsert: []models.ListItem{newItem("item-003", "etag-003", "Test Item 3")},
+	}, models.SyncProgress{Full: true, First: true, Pages: 1, NextLink: stringPtr("next-link-001"), SeenIDs: []string{"item-001", "item-003"}})
+	require.NoError(t, err)
+
+	checkpoint, err := db.GetSyncCheckpoint(context.Background(), "site-001", "list-001")
+	require.NoError(t, err)
+	require.NotNil(t, checkpoint, "Intermediate page should store a checkpoint")
+	assert.Equal(t, "next-link-001", checkpoint.NextLink)
+	assert.True(t, checkpoint.Full)
+	assert.Equal(t, 1, checkpoint.Pages)
+
+	var seen []string
+	rows, err := db.Connection.QueryContext(context.Background(), "SELECT item_id FROM sync_seen_items ORDER BY item_id;")
+	require.NoError(t, err)
+	for rows.Next() {
+		var id string
+		require.NoError(t, rows.Scan(&id))
+		seen = append(seen, id)
+	}
+	require.NoError(t, rows.Close())
+	assert.Equal(t, []string{"item-001", "item-003"}, seen, "First page should replace the items seen by an abandoned sync")
+
+	// The last page only knows its own items, the earlier ones come from the seen items
+	counts, err := db.ApplyListItemChanges(context.Background(), list, models.ListItemChanges{
+		Upsert: []models.ListItem{newItem("item-004", "etag-004", "Test Item 4")},
+	}, models.SyncProgress{Full: true, Pages: 2, DeltaLink: stringPtr("delta-link-001"),
+		SeenIDs: []string{"item-004"}})
+	require.NoError(t, err)
+	assert.Equal(t, models.ChangeCounts{Inserted: 1, Deleted: 1}, counts, "The unseen item should be deleted")
+
+	checkpoint, err = db.GetSyncCheckpoint(context.Background(), "site-001", "list-001")
+	require.NoError(t, err)
+	assert.Nil(t, checkpoint, "Last page should remove the checkpoint")
+
+	deltaLink, err := db.GetDeltaLink(context.Background(), "list-001")
+	require.NoError(t, err)
+	assert.Equal(t, "delta-link-001", *deltaLink)
 
-	// Verify data was deleted
 	var count int
-	err = db.Connection.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM list_items;").Scan(&count)
-	assert.NoError(t, err, "Failed to query row count")
-	assert.Equal(t, 0, count, "Expected 0 rows after deletion")
+	err = db.Connection.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM list_items WHERE id = 'item-009';").Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "Items of other lists should be kept")
+
+	err = db.Connection.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM list_items WHERE list_id = 'list-001';").Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count, "Items seen by every page should be kept")
+
+	err = db.Connection.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM sync_seen_items;").Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count, "Last page should remove the seen items")
 }
@@ -0,0 +1,67 @@
+//go:build testing && integration
+
+package database_test
+
+import (
+	"context"
+	"microsoft-apps-exporter/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSubscriptionsLifecycle tests saving, updating and deleting persisted subscriptions.
+func TestSubscriptionsLifecycle(t *testing.T) {
+	db := setupMigratedDatabase(t)
+	defer teardownTestDatabase(db)
+
+	expiry := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+	subscription := models.GraphSubscription{
+		ID:                       "sub-001",
+		Resource:                 "sites/site-001/lists/list-001",
+		NotificationURL:          "https://example.com/webhook/sharepoint",
+		LifecycleNotificationURL: "https://example.com/webhook/subscription-notification",
+		ClientState:              "secret",
+		ExpirationDateTime:       expiry,
+	}
+	require.NoError(t, db.SaveSubscription(context.Background(), subscription))
+
+	subscriptions, err := db.GetSubscriptions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+	assert.Equal(t, subscription.Resource, subscriptions[0].Resource)
+	assert.Equal(t, subscription.ClientState, subscriptions[0].ClientState)
+	assert.True(t, expiry.Equal(subscriptions[0].ExpirationDateTime))
+	assert.False(t, subscriptions[0].CreatedAt.IsZero(), "Creation time should be set by the database")
+	assert.Nil(t, subscriptions[0].LastNotificationAt)
+
+	renewed := expiry.Add(24 * time.Hour)
+	require.NoError(t, db.UpdateSubscriptionExpiry(context.Background(), "sub-001", renewed))
+
+	notifiedAt := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, db.SaveSubscriptionNotification(context.Background(), "sub-001", notifiedAt))
+
+	// Saving again must keep the notification time
+	require.NoError(t, db.SaveSubscription(context.Background(), models.GraphSubscription{
+		ID:                       "sub-001",
+		Resource:                 subscription.Resource,
+		NotificationURL:          subscription.NotificationURL,
+		LifecycleNotificationURL: subscription.LifecycleNotificationURL,
+		ClientState:              subscription.ClientState,
+		ExpirationDateTime:       renewed,
+	}))
+
+	subscriptions, err = db.GetSubscriptions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+	assert.True(t, renewed.Equal(subscriptions[0].ExpirationDateTime))
+	require.NotNil(t, subscriptions[0].LastNotificationAt)
+	assert.True(t, notifiedAt.Equal(*subscriptions[0].LastNotificationAt))
+
+	require.NoError(t, db.DeleteSubscription(context.Background(), "sub-001"))
+	subscriptions, err = db.GetSubscriptions(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, subscriptions)
+}
@@ -0,0 +1,62 @@
+//go:build testing && integration
+
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSyncFailures tests recording, scheduling and clearing sync failures.
+func TestSyncFailures(t *testing.T) {
+	db := setupMigratedDatabase(t)
+	defer teardownTestDatabase(db)
+
+	attempts, err := db.SaveSyncFailure(context.Background(), "site-001", "list-001", false, "first error")
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempts)
+
+	attempts, err = db.SaveSyncFailure(context.Background(), "site-001", "list-001", true, "second error")
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts, "Repeated failures should increment the attempts")
+
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+	require.NoError(t, db.ScheduleSyncFailure(context.Background(), "site-001", "list-001", &past))
+
+	_, err = db.SaveSyncFailure(context.Background(), "site-001", "list-002", false, "other error")
+	require.NoError(t, err)
+	require.NoError(t, db.ScheduleSyncFailure(context.Background(), "site-001", "list-002", &future))
+
+	_, err = db.SaveSyncFailure(context.Background(), "site-001", "list-003", false, "exhausted error")
+	require.NoError(t, err)
+	require.NoError(t, db.ScheduleSyncFailure(context.Background(), "site-001", "list-003", nil))
+
+	due, err := db.GetDueSyncFailures(context.Background(), now)
+	require.NoError(t, err)
+	require.Len(t, due, 1, "Only the failure with a past next attempt should be due")
+	assert.Equal(t, "list-001", due[0].ListID)
+	assert.Equal(t, "second error", due[0].Error)
+	assert.Equal(t, 2, due[0].Attempts)
+	assert.True(t, due[0].Full, "A failed full resync should keep the retry a full resync")
+
+	failure, err := db.GetSyncFailure(context.Background(), "site-001", "list-002")
+	require.NoError(t, err)
+	require.NotNil(t, failure)
+	assert.Equal(t, "other error", failure.Error)
+	assert.False(t, failure.Full)
+	require.NotNil(t, failure.NextAttemptAt)
+
+	failure, err = db.GetSyncFailure(context.Background(), "site-001", "list-404")
+	require.NoError(t, err)
+	assert.Nil(t, failure, "A list without failure should return nil")
+
+	require.NoError(t, db.DeleteSyncFailure(context.Background(), "site-001", "list-001"))
+	due, err = db.GetDueSyncFailures(context.Background(), now)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+}
@@ -0,0 +1,48 @@
+//go:build testing && integration
+
+package database_test
+
+import (
+	"context"
+	"microsoft-apps-exporter/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPendingSyncs tests storing pending syncs at shutdown and removing them once resumed.
+func TestPendingSyncs(t *testing.T) {
+	db := setupMigratedDatabase(t)
+	defer teardownTestDatabase(db)
+
+	require.NoError(t, db.SavePendingSyncs(context.Background(), []models.PendingSync{
+		{SiteID: "site-001", ListID: "list-001", Full: true, Trigger: "webhook"},
+		{SiteID: "site-001", ListID: "list-002", Trigger: "schedule"},
+	}))
+	require.NoError(t, db.SavePendingSyncs(context.Background(), []models.PendingSync{
+		{SiteID: "site-001", ListID: "list-001", Trigger: "retry"},
+	}))
+
+	syncs, err := db.GetPendingSyncs(context.Background())
+	require.NoError(t, err)
+	require.Len(t, syncs, 2, "A list should be stored once")
+
+	byList := map[string]models.PendingSync{}
+	for _, sync := range syncs {
+		byList[sync.ListID] = sync
+	}
+	assert.True(t, byList["list-001"].Full, "A pending full resync should stay a full resync")
+	assert.Equal(t, "webhook", byList["list-001"].Trigger)
+	assert.False(t, byList["list-002"].Full)
+
+	syncs, err = db.GetPendingSyncs(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, syncs, 2, "Pending syncs should be kept until they have run")
+
+	require.NoError(t, db.DeletePendingSync(context.Background(), "site-001", "list-001"))
+	syncs, err = db.GetPendingSyncs(context.Background())
+	require.NoError(t, err)
+	require.Len(t, syncs, 1, "The pending sync that has run should be removed")
+	assert.Equal(t, "list-002", syncs[0].ListID)
+}
@@ -0,0 +1,54 @@
+//go:build testing && integration
+
+package database_test
+
+import (
+	"context"
+	"microsoft-apps-exporter/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSyncRuns tests saving and retrieving the sync run history of a list.
+func TestSyncRuns(t *testing.T) {
+	db := setupMigratedDatabase(t)
+	defer teardownTestDatabase(db)
+
+	started := time.Now().UTC().Truncate(time.Second)
+	failure := "failed to sync list items"
+
+	runs := []models.SyncRun{
+		{SiteID: "site-001", ListID: "list-001", Trigger: "startup", Full: true, StartedAt: started,
+			FinishedAt: started.Add(time.Minute), Inserted: 120, Pages: 3},
+		{SiteID: "site-001", ListID: "list-001", Trigger: "webhook", StartedAt: started.Add(time.Hour),
+			FinishedAt: started.Add(time.Hour + time.Second), Updated: 2, Deleted: 1, Pages: 1, Error: &failure},
+		{SiteID: "site-001", ListID: "list-002", Trigger: "schedule", StartedAt: started,
+			FinishedAt: started.Add(time.Second), Pages: 1},
+	}
+	for _, run := range runs {
+		require.NoError(t, db.SaveSyncRun(context.Background(), run))
+	}
+
+	history, err := db.GetSyncRuns(context.Background(), "site-001", "list-001", 10)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	latest := history[0]
+	assert.Equal(t, "webhook", latest.Trigger, "Newest run should be returned first")
+	assert.False(t, latest.Full)
+	assert.Equal(t, 2, latest.Updated)
+	assert.Equal(t, 1, latest.Deleted)
+	require.NotNil(t, latest.Error)
+	assert.Equal(t, failure, *latest.Error)
+
+	assert.Equal(t, 120, history[1].Inserted)
+	assert.Equal(t, 3, history[1].Pages)
+	assert.Nil(t, history[1].Error)
+
+	history, err = db.GetSyncRuns(context.Background(), "site-001", "list-001", 1)
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+}
@@ -0,0 +1,105 @@
+//go:build testing && unit
+
+package api_test
+
+import (
+	"context"
+	"encoding/hex"
+	"log/slog"
+	"math"
+	"microsoft-apps-exporter/internal/api"
+	"microsoft-apps-exporter/internal/configuration"
+	"testing"
+
+	gmodels "github.com/microsoftgraph/msgraph-sdk-go/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupClientStateConfig reloads configuration with the given configured client state.
+func setupClientStateConfig(t *testing.T, clientState string) {
+	t.Setenv("WEBHOOK_CLIENT_STATE", clientState)
+	configuration.ResetConfig()
+	t.Cleanup(configuration.ResetConfig)
+}
+
+// newTestSubscription builds a subscription with the given ID and client state.
+func newTestSubscription(id string, clientState *string) gmodels.Subscriptionable {
+	subscription := gmodels.NewSubscription()
+	subscription.SetId(&id)
+	subscription.SetClientState(clientState)
+	return subscription
+}
+
+// TestNewClientState verifies generated secrets are random and configured secrets take precedence.
+func TestNewClientState(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	t.Run("Generated", func(t *testing.T) {
+		setupClientStateConfig(t, "")
+
+		first, err := api.NewClientState()
+		assert.NoError(t, err)
+		second, err := api.NewClientState()
+		assert.NoError(t, err)
+
+		_, err = hex.DecodeString(first)
+		assert.NoError(t, err, "Generated client state should be hex encoded")
+		assert.Len(t, first, 64)
+		assert.NotEqual(t, first, second, "Generated client states should differ per subscription")
+	})
+
+	t.Run("Configured", func(t *testing.T) {
+		setupClientStateConfig(t, "configured-secret")
+
+		clientState, err := api.NewClientState()
+		assert.NoError(t, err)
+		assert.Equal(t, "configured-secret", clientState)
+	})
+}
+
+// TestVerifyClientState checks notification verification against known and configured secrets.
+func TestVerifyClientState(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	t.Run("Generated", func(t *testing.T) {
+		setupClientStateConfig(t, "")
+		graph := &api.GraphHelper{}
+		graph.TrackSubscription("sub-1", "", "secret")
+
+		assert.True(t, graph.VerifyClientState("sub-1", "secret"))
+		assert.False(t, graph.VerifyClientState("sub-1", "forged"))
+		assert.False(t, graph.VerifyClientState("sub-1", ""))
+		assert.False(t, graph.VerifyClientState("sub-unknown", "secret"))
+	})
+
+	t.Run("Configured", func(t *testing.T) {
+		setupClientStateConfig(t, "configured-secret")
+		graph := &api.GraphHelper{}
+
+		assert.True(t, graph.VerifyClientState("sub-unknown", "configured-secret"))
+		assert.False(t, graph.VerifyClientState("sub-unknown", "forged"))
+	})
+}
+
+// TestAdoptClientState checks which existing subscriptions can be reused without recreation.
+func TestAdoptClientState(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+	secret, other := "secret", "other"
+
+	t.Run("Generated", func(t *testing.T) {
+		setupClientStateConfig(t, "")
+		graph := &api.GraphHelper{}
+
+		assert.False(t, graph.AdoptClientState(context.Background(), newTestSubscription("sub-1", nil)), "Unknown secret requires recreation")
+		assert.True(t, graph.AdoptClientState(context.Background(), newTestSubscription("sub-2", &secret)), "Secret returned by API should be adopted")
+		assert.True(t, graph.VerifyClientState("sub-2", secret))
+	})
+
+	t.Run("Configured", func(t *testing.T) {
+		setupClientStateConfig(t, "secret")
+		graph := &api.GraphHelper{}
+
+		assert.True(t, graph.AdoptClientState(context.Background(), newTestSubscription("sub-1", &secret)))
+		assert.False(t, graph.AdoptClientState(context.Background(), newTestSubscription("sub-2", &other)), "Outdated secret requires recreation")
+	})
+}
@@ -0,0 +1,71 @@
+//go:build testing && unit
+
+package api_test
+
+import (
+	"context"
+	"errors"
+	"microsoft-apps-exporter/internal/api"
+	"microsoft-apps-exporter/internal/metrics"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingPipeline fails every request with a transport error.
+type failingPipeline struct{}
+
+func (failingPipeline) Next(*http.Request, int) (*http.Response, error) {
+	return nil, errors.New("connection reset")
+}
+
+// TestMetricsHandler verifies Graph requests are counted per operation and status code.
+func TestMetricsHandler(t *testing.T) {
+	handler := api.NewMetricsHandler()
+	ctx := api.WithOperation(context.Background(), "metricsTest")
+
+	pipeline := &fakePipeline{responses: []*http.Response{newResponse(http.StatusOK, "")}}
+	for range 2 {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://graph.microsoft.com/v1.0/sites", nil)
+		require.NoError(t, err)
+		_, err = handler.Intercept(pipeline, 0, req)
+		require.NoError(t, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://graph.microsoft.com/v1.0/sites", nil)
+	require.NoError(t, err)
+	_, err = handler.Intercept(failingPipeline{}, 0, req)
+	require.Error(t, err)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.GraphRequests.WithLabelValues("metricsTest", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.GraphRequests.WithLabelValues("metricsTest", "error")))
+}
+
+// TestSubscriptionExpiryCollector verifies the remaining lifetime is exposed for subscriptions with a known expiry.
+func TestSubscriptionExpiryCollector(t *testing.T) {
+	graph := &api.GraphHelper{}
+	graph.TrackSubscriptionExpiry("sub-1", "sites/a/lists/b", time.Now().Add(time.Hour))
+	graph.TrackSubscriptionExpiry("sub-2", "sites/a/lists/c", time.Time{})
+
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(api.NewSubscriptionExpiryCollector(graph)))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	require.Len(t, families[0].GetMetric(), 1)
+
+	metric := families[0].GetMetric()[0]
+	assert.InDelta(t, time.Hour.Seconds(), metric.GetGauge().GetValue(), 60)
+
+	labels := map[string]string{}
+	for _, label := range metric.GetLabel() {
+		labels[label.GetName()] = label.GetValue()
+	}
+	assert.Equal(t, map[string]string{"subscription_id": "sub-1", "resource": "sites/a/lists/b"}, labels)
+}
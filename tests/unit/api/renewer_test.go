@@ -0,0 +1,57 @@
+//go:build testing && unit
+
+package api_test
+
+import (
+	"microsoft-apps-exporter/internal/api"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDueForRenewal verifies subscriptions are renewed once they expire within the safety margin.
+func TestDueForRenewal(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	margin := 12 * time.Hour
+
+	tests := []struct {
+		name       string
+		expiration time.Time
+		expected   bool
+	}{
+		{"Unknown expiration", time.Time{}, true},
+		{"Already expired", now.Add(-time.Minute), true},
+		{"Within margin", now.Add(6 * time.Hour), true},
+		{"Exactly at margin", now.Add(margin), true},
+		{"Beyond margin", now.Add(24 * time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, api.DueForRenewal(tt.expiration, now, margin))
+		})
+	}
+}
+
+// TestNextSubscriptionExpiry verifies the earliest known expiration is reported.
+func TestNextSubscriptionExpiry(t *testing.T) {
+	graph := &api.GraphHelper{}
+
+	_, ok := graph.NextSubscriptionExpiry()
+	assert.False(t, ok, "No subscriptions should report no expiry")
+
+	now := time.Now()
+	graph.TrackSubscriptionExpiry("sub-1", "sites/a/lists/1", now.Add(48*time.Hour))
+	graph.TrackSubscriptionExpiry("sub-2", "sites/a/lists/2", now.Add(24*time.Hour))
+	graph.TrackSubscriptionExpiry("sub-3", "sites/a/lists/3", time.Time{})
+
+	next, ok := graph.NextSubscriptionExpiry()
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(24*time.Hour), next)
+
+	renewer := &api.SubscriptionRenewer{Graph: graph}
+	renewerNext, ok := renewer.NextExpiry()
+	assert.True(t, ok)
+	assert.Equal(t, next, renewerNext)
+}
@@ -0,0 +1,210 @@
+//go:build testing && unit
+
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"microsoft-apps-exporter/internal/api"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	khttp "github.com/microsoft/kiota-http-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePipeline answers requests with the given status codes in order and records the received requests.
+type fakePipeline struct {
+	responses []*http.Response
+	requests  []*http.Request
+	bodies    []string
+}
+
+func (p *fakePipeline) Next(req *http.Request, _ int) (*http.Response, error) {
+	p.requests = append(p.requests, req)
+	if req.Body != nil {
+		body, _ := io.ReadAll(req.Body)
+		p.bodies = append(p.bodies, string(body))
+	}
+
+	response := p.responses[0]
+	if len(p.responses) > 1 {
+		p.responses = p.responses[1:]
+	}
+	return response, nil
+}
+
+// newResponse builds a response with the given status and Retry-After header.
+func newResponse(status int, retryAfter string) *http.Response {
+	response := &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(""))}
+	if retryAfter != "" {
+		response.Header.Set("Retry-After", retryAfter)
+	}
+	return response
+}
+
+// recordSleeps returns a sleep function recording the requested delays without waiting.
+func recordSleeps(delays *[]time.Duration) func(context.Context, time.Duration) error {
+	return func(_ context.Context, delay time.Duration) error {
+		*delays = append(*delays, delay)
+		return nil
+	}
+}
+
+// newGraphRequest builds a request annotated with a Graph operation name.
+func newGraphRequest(t *testing.T, method string, body io.ReadSeeker) *http.Request {
+	req, err := http.NewRequestWithContext(api.WithOperation(context.Background(), "requestList"),
+		method, "https://graph.microsoft.com/v1.0/sites", nil)
+	require.NoError(t, err)
+	if body != nil {
+		req.Body = khttp.NopCloser(body)
+	}
+	return req
+}
+
+// TestRetryHandler_RetriesTransientErrors verifies throttled and unavailable responses are retried until success.
+func TestRetryHandler_RetriesTransientErrors(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusGatewayTimeout} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var delays []time.Duration
+			pipeline := &fakePipeline{responses: []*http.Response{newResponse(status, ""), newResponse(http.StatusOK, "")}}
+			handler := api.NewRetryHandler(3, time.Second, time.Minute, time.Hour, recordSleeps(&delays))
+
+			response, err := handler.Intercept(pipeline, 0, newGraphRequest(t, http.MethodGet, nil))
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, response.StatusCode)
+			assert.Len(t, pipeline.requests, 2)
+			assert.Equal(t, "1", pipeline.requests[1].Header.Get("Retry-Attempt"))
+			require.Len(t, delays, 1)
+			assert.GreaterOrEqual(t, delays[0], 500*time.Millisecond, "Jitter should keep half of the backoff")
+			assert.LessOrEqual(t, delays[0], time.Second)
+		})
+	}
+}
+
+// TestRetryHandler_NonRetriable ensures other failures are returned as-is.
+func TestRetryHandler_NonRetriable(t *testing.T) {
+	var delays []time.Duration
+	pipeline := &fakePipeline{responses: []*http.Response{newResponse(http.StatusNotFound, "")}}
+	handler := api.NewRetryHandler(3, time.Second, time.Minute, time.Hour, recordSleeps(&delays))
+
+	response, err := handler.Intercept(pipeline, 0, newGraphRequest(t, http.MethodGet, nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	assert.Len(t, pipeline.requests, 1)
+	assert.Empty(t, delays)
+}
+
+// TestRetryHandler_HonorsRetryAfter verifies the server provided delay takes precedence over the backoff.
+func TestRetryHandler_HonorsRetryAfter(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	var delays []time.Duration
+	pipeline := &fakePipeline{responses: []*http.Response{newResponse(http.StatusTooManyRequests, "7"), newResponse(http.StatusOK, "")}}
+	handler := api.NewRetryHandler(3, time.Second, time.Minute, time.Hour, recordSleeps(&delays))
+
+	_, err := handler.Intercept(pipeline, 0, newGraphRequest(t, http.MethodGet, nil))
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{7 * time.Second}, delays)
+}
+
+// TestRetryHandler_ExponentialBackoff verifies delays grow exponentially and are capped.
+func TestRetryHandler_ExponentialBackoff(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	var delays []time.Duration
+	pipeline := &fakePipeline{responses: []*http.Response{newResponse(http.StatusServiceUnavailable, "")}}
+	handler := api.NewRetryHandler(5, time.Second, 4*time.Second, time.Hour, recordSleeps(&delays))
+
+	response, err := handler.Intercept(pipeline, 0, newGraphRequest(t, http.MethodGet, nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode, "Last response should be returned once retries run out")
+	assert.Len(t, pipeline.requests, 6, "Expected the initial request plus 5 retries")
+
+	upperBounds := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second, 4 * time.Second}
+	require.Len(t, delays, len(upperBounds))
+	for i, upper := range upperBounds {
+		assert.GreaterOrEqual(t, delays[i], upper/2)
+		assert.LessOrEqual(t, delays[i], upper)
+	}
+}
+
+// TestRetryHandler_Budget ensures retries stop once the next delay would exceed the retry budget.
+func TestRetryHandler_Budget(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	var delays []time.Duration
+	pipeline := &fakePipeline{responses: []*http.Response{newResponse(http.StatusTooManyRequests, "10")}}
+	handler := api.NewRetryHandler(10, time.Second, time.Minute, 25*time.Second, recordSleeps(&delays))
+
+	response, err := handler.Intercept(pipeline, 0, newGraphRequest(t, http.MethodGet, nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+	assert.Equal(t, []time.Duration{10 * time.Second, 10 * time.Second}, delays)
+	assert.Len(t, pipeline.requests, 3)
+}
+
+// TestRetryHandler_ReplaysBody verifies the request body is sent again on retry.
+func TestRetryHandler_ReplaysBody(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	var delays []time.Duration
+	pipeline := &fakePipeline{responses: []*http.Response{newResponse(http.StatusGatewayTimeout, "0"), newResponse(http.StatusCreated, "")}}
+	handler := api.NewRetryHandler(3, time.Second, time.Minute, time.Hour, recordSleeps(&delays))
+
+	req := newGraphRequest(t, http.MethodPost, bytes.NewReader([]byte(`{"resource":"sites/a/lists/b"}`)))
+	response, err := handler.Intercept(pipeline, 0, req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+	assert.Equal(t, []string{`{"resource":"sites/a/lists/b"}`, `{"resource":"sites/a/lists/b"}`}, pipeline.bodies)
+}
+
+// TestRetryHandler_Cancelled ensures waiting for a retry is aborted with the request context.
+func TestRetryHandler_Cancelled(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	cancelled := errors.New("cancelled")
+	pipeline := &fakePipeline{responses: []*http.Response{newResponse(http.StatusTooManyRequests, "1")}}
+	handler := api.NewRetryHandler(3, time.Second, time.Minute, time.Hour, func(context.Context, time.Duration) error {
+		return cancelled
+	})
+
+	_, err := handler.Intercept(pipeline, 0, newGraphRequest(t, http.MethodGet, nil))
+	assert.ErrorIs(t, err, cancelled)
+	assert.Len(t, pipeline.requests, 1)
+}
+
+// TestRetryAfter tests parsing of the Retry-After header.
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"Missing", "", 0, false},
+		{"Seconds", "30", 30 * time.Second, true},
+		{"HTTP date", now.Add(2 * time.Minute).Format(http.TimeFormat), 2 * time.Minute, true},
+		{"Past date", now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"Invalid", "soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, ok := api.RetryAfter(newResponse(http.StatusTooManyRequests, tt.value), now)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, delay)
+		})
+	}
+}
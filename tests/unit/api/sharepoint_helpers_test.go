@@ -8,6 +8,7 @@ import (
 	"microsoft-apps-exporter/internal/api"
 	"microsoft-apps-exporter/internal/models"
 
+	gmodels "github.com/microsoftgraph/msgraph-sdk-go/models"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -27,3 +28,68 @@ func TestDeserializeFields(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+// TestParseColumnDefinition tests the type of a column is told by its facet.
+func TestParseColumnDefinition(t *testing.T) {
+	newColumn := func(name string) *gmodels.ColumnDefinition {
+		column := gmodels.NewColumnDefinition()
+		column.SetName(&name)
+		return column
+	}
+	boolPtr := func(value bool) *bool { return &value }
+	stringPtr := func(value string) *string { return &value }
+
+	t.Run("Bounded text", func(t *testing.T) {
+		column := newColumn("Nickname")
+		text := gmodels.NewTextColumn()
+		maxLength := int32(20)
+		text.SetMaxLength(&maxLength)
+		column.SetText(text)
+		column.SetRequired(boolPtr(true))
+
+		assert.Equal(t, models.ColumnDefinition{Name: "Nickname", Type: models.ColumnTypeText, MaxLength: 20, Required: true},
+			api.ParseColumnDefinition(column))
+	})
+
+	t.Run("Multiple lines text", func(t *testing.T) {
+		column := newColumn("Comment")
+		text := gmodels.NewTextColumn()
+		text.SetAllowMultipleLines(boolPtr(true))
+		column.SetText(text)
+
+		assert.Equal(t, models.ColumnTypeNote, api.ParseColumnDefinition(column).Type)
+	})
+
+	t.Run("Date only", func(t *testing.T) {
+		column := newColumn("Birthday")
+		dateTime := gmodels.NewDateTimeColumn()
+		dateTime.SetFormat(stringPtr("dateOnly"))
+		column.SetDateTime(dateTime)
+
+		assert.Equal(t, models.ColumnTypeDate, api.ParseColumnDefinition(column).Type)
+	})
+
+	t.Run("Multiple choices", func(t *testing.T) {
+		column := newColumn("Tags")
+		choice := gmodels.NewChoiceColumn()
+		choice.SetDisplayAs(stringPtr("checkBoxes"))
+		column.SetChoice(choice)
+
+		parsed := api.ParseColumnDefinition(column)
+		assert.Equal(t, models.ColumnTypeChoice, parsed.Type)
+		assert.True(t, parsed.Multiple)
+	})
+
+	t.Run("Calculated number", func(t *testing.T) {
+		column := newColumn("AvgScore")
+		calculated := gmodels.NewCalculatedColumn()
+		calculated.SetOutputType(stringPtr("number"))
+		column.SetCalculated(calculated)
+
+		assert.Equal(t, models.ColumnTypeNumber, api.ParseColumnDefinition(column).Type)
+	})
+
+	t.Run("No facet", func(t *testing.T) {
+		assert.Equal(t, models.ColumnTypeText, api.ParseColumnDefinition(newColumn("Title")).Type)
+	})
+}
@@ -0,0 +1,58 @@
+//go:build testing && unit
+
+package api_test
+
+import (
+	"log/slog"
+	"math"
+	"microsoft-apps-exporter/internal/api"
+	"microsoft-apps-exporter/internal/configuration"
+	"testing"
+
+	gmodels "github.com/microsoftgraph/msgraph-sdk-go/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// newOwnershipSubscription builds a subscription with the given ID and notification URLs.
+func newOwnershipSubscription(id, notificationURL, lifecycleURL string) gmodels.Subscriptionable {
+	subscription := gmodels.NewSubscription()
+	subscription.SetId(&id)
+	subscription.SetNotificationUrl(&notificationURL)
+	subscription.SetLifecycleNotificationUrl(&lifecycleURL)
+	return subscription
+}
+
+// TestIsSubscriptionOwned verifies only subscriptions of this deployment are considered owned.
+func TestIsSubscriptionOwned(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	t.Setenv("WEBHOOK_EXTERNAL_BASE_URL", "https://prod.example.com/")
+	configuration.ResetConfig()
+	t.Cleanup(configuration.ResetConfig)
+
+	graph := &api.GraphHelper{}
+	graph.TrackSubscription("tracked", "sites/a/lists/1", "secret")
+
+	tests := []struct {
+		name         string
+		subscription gmodels.Subscriptionable
+		expected     bool
+	}{
+		{"Own notification URL", newOwnershipSubscription("sub-1",
+			"https://prod.example.com/webhook/sharepoint", "https://prod.example.com/webhook/subscription-notification"), true},
+		{"Own lifecycle URL only", newOwnershipSubscription("sub-2",
+			"https://old.example.com/webhook/sharepoint", "https://prod.example.com/webhook/subscription-notification"), true},
+		{"Other deployment", newOwnershipSubscription("sub-3",
+			"https://staging.example.com/webhook/sharepoint", "https://staging.example.com/webhook/subscription-notification"), false},
+		{"Shared host prefix", newOwnershipSubscription("sub-4",
+			"https://prod.example.com.evil/webhook/sharepoint", "https://prod.example.com.evil/webhook/subscription-notification"), false},
+		{"Tracked by this instance", newOwnershipSubscription("tracked",
+			"https://previous.example.com/webhook/sharepoint", "https://previous.example.com/webhook/subscription-notification"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, graph.IsSubscriptionOwned(tt.subscription))
+		})
+	}
+}
@@ -34,6 +34,17 @@ func TestHandleBadRequest(t *testing.T) {
 	}
 }
 
+// TestHandleForbidden verifies that rejected requests return a 403 response.
+func TestHandleForbidden(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+	w := httptest.NewRecorder()
+	webhook.HandleForbidden(w, "Client state mismatch")
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status code 403, got %d", w.Code)
+	}
+}
+
 // TestHandleInternalError verifies that internal errors return a 500 response.
 func TestHandleInternalError(t *testing.T) {
 	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
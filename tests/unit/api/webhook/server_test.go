@@ -54,6 +54,12 @@ func TestNewWebhookServer(t *testing.T) {
 			method:     http.MethodGet,
 			wantStatus: http.StatusOK,
 		},
+		{
+			name:       "Metrics Handler",
+			path:       "/metrics",
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+		},
 		{
 			name:       "Invalid Endpoint",
 			path:       "/webhook/invalid",
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"log/slog"
 	"math"
+	"microsoft-apps-exporter/internal/api"
 	"microsoft-apps-exporter/internal/api/webhook"
 	"microsoft-apps-exporter/internal/configuration"
 	"microsoft-apps-exporter/internal/sync"
@@ -34,7 +35,9 @@ func TestNewSharepointHandler(t *testing.T) {
 	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
 	setupTestResourcesYaml()
 
-	syncer := &sync.Syncer{}
+	graph := &api.GraphHelper{}
+	graph.TrackSubscription("sub-1", "", "secret")
+	syncer := sync.NewSyncer(graph, nil)
 	handler := webhook.NewSharepointHandler(syncer)
 
 	tests := []struct {
@@ -69,6 +72,43 @@ func TestNewSharepointHandler(t *testing.T) {
 			body:           []byte(`{"value": [{"resource": "invalid/resource"}]}`),
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name:   "Invalid notifications skipped",
+			method: http.MethodPost,
+			body: []byte(`{"value": [
+				{"subscriptionId": "sub-1", "clientState": "secret", "resource": "invalid/resource", "resourceData": {"@odata.type": "#Microsoft.Graph.ListItem"}},
+				{"subscriptionId": "sub-1", "clientState": "secret", "resource": "sites/site_id1/lists/list_id1", "resourceData": {"@odata.type": "#Microsoft.Graph.ListItem"}}
+			]}`),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "Client state mismatch",
+			method: http.MethodPost,
+			body: []byte(`{"value": [
+				{"subscriptionId": "sub-1", "clientState": "forged", "resource": "sites/site_id1/lists/list_id1", "resourceData": {"@odata.type": "#Microsoft.Graph.ListItem"}}
+			]}`),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "Only unknown resources",
+			method: http.MethodPost,
+			body: []byte(`{"value": [
+				{"subscriptionId": "sub-1", "clientState": "secret", "resource": "sites/site999/lists/listX", "resourceData": {"@odata.type": "#Microsoft.Graph.ListItem"}}
+			]}`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Batched known, unknown and forged resources",
+			method: http.MethodPost,
+			body: []byte(`{"value": [
+				{"subscriptionId": "sub-1", "clientState": "secret", "resource": "sites/site_id1/lists/list_id1", "resourceData": {"@odata.type": "#Microsoft.Graph.ListItem"}},
+				{"subscriptionId": "sub-1", "clientState": "secret", "resource": "sites/site999/lists/listX", "resourceData": {"@odata.type": "#Microsoft.Graph.ListItem"}},
+				{"subscriptionId": "sub-1", "clientState": "secret", "resource": "sites/site_id2/lists/list_id2", "resourceData": {"@odata.type": "#Microsoft.Graph.ListItem"}},
+				{"subscriptionId": "sub-1", "clientState": "forged", "resource": "sites/site_id2/lists/list_id3", "resourceData": {"@odata.type": "#Microsoft.Graph.ListItem"}},
+				{"subscriptionId": "sub-1", "clientState": "secret", "resource": "sites/site_id1/lists/list_id1", "resourceData": {"@odata.type": "#Microsoft.Graph.ListItem"}}
+			]}`),
+			expectedStatus: http.StatusOK,
+		},
 	}
 
 	for _, tt := range tests {
@@ -84,6 +124,8 @@ func TestNewSharepointHandler(t *testing.T) {
 			assert.Equal(t, tt.expectedStatus, w.Code)
 		})
 	}
+
+	assert.Equal(t, 2, syncer.Queue.Len(), "Each known list should be queued exactly once")
 }
 
 // TestExctractListReference tests the extraction of ListReference based on site and list IDs.
@@ -114,70 +156,92 @@ func TestExctractListReference(t *testing.T) {
 	}
 }
 
-// TestExtractResourceUpdateData tests the extraction of resource update data from the request body.
-func TestExtractResourceUpdateData(t *testing.T) {
-	validBody := webhook.ResourceUpdateBody{
-		Value: []struct {
-			Resource     string `json:"resource"`
-			ResourceData struct {
-				OdataType string `json:"@odata.type"`
-			} `json:"resourceData"`
-		}{
-			{
-				Resource: "sites/site123/lists/listA",
-				ResourceData: struct {
-					OdataType string `json:"@odata.type"`
-				}{
-					OdataType: "#Microsoft.Graph.ListItem",
-				},
-			},
-		},
-	}
-
-	invalidBodyType := webhook.ResourceUpdateBody{
-		Value: []struct {
-			Resource     string `json:"resource"`
-			ResourceData struct {
-				OdataType string `json:"@odata.type"`
-			} `json:"resourceData"`
-		}{
-			{
-				Resource: "sites/site123/lists/listA",
-				ResourceData: struct {
-					OdataType string `json:"@odata.type"`
-				}{
-					OdataType: "invalidType",
-				},
-			},
-		},
+// newNotification builds a SharePoint change notification for the given resource and data type.
+func newNotification(resource, odataType string) webhook.ResourceUpdateNotification {
+	return webhook.ResourceUpdateNotification{
+		Resource:     resource,
+		ResourceData: webhook.ResourceData{OdataType: odataType},
 	}
+}
 
-	invalidResource := webhook.ResourceUpdateBody{
-		Value: []struct {
-			Resource     string `json:"resource"`
-			ResourceData struct {
-				OdataType string `json:"@odata.type"`
-			} `json:"resourceData"`
-		}{
-			{
-				Resource: "invalid/resource/format",
-				ResourceData: struct {
-					OdataType string `json:"@odata.type"`
-				}{
-					OdataType: "#Microsoft.Graph.ListItem",
-				},
-			},
-		},
-	}
+// TestExtractResourceUpdateData tests the extraction of resource update data from the request body.
+func TestExtractResourceUpdateData(t *testing.T) {
+	const listItemType = "#Microsoft.Graph.ListItem"
+	verifyAll := func(webhook.ResourceUpdateNotification) bool { return true }
 
 	tests := []struct {
 		name        string
 		body        webhook.ResourceUpdateBody
+		verify      func(webhook.ResourceUpdateNotification) bool
+		expected    []webhook.ListResource
 		expectError bool
+		expectedErr error
 	}{
-		{"Valid Resource", validBody, false},
-		{"Invalid Data Type", invalidBodyType, true},
-		{"Invalid Resource Format", invalidResource, true},
+		{
+			name: "Valid Resource",
+			body: webhook.ResourceUpdateBody{Value: []webhook.ResourceUpdateNotification{
+				newNotification("sites/site123/lists/listA", listItemType),
+			}},
+			expected: []webhook.ListResource{{SiteID: "site123", ListID: "listA"}},
+		},
+		{
+			name: "Batched Resources Deduplicated",
+			body: webhook.ResourceUpdateBody{Value: []webhook.ResourceUpdateNotification{
+				newNotification("sites/site123/lists/listA", listItemType),
+				newNotification("sites/site123/lists/listB", listItemType),
+				newNotification("sites/site123/lists/listA", listItemType),
+			}},
+			expected: []webhook.ListResource{
+				{SiteID: "site123", ListID: "listA"},
+				{SiteID: "site123", ListID: "listB"},
+			},
+		},
+		{
+			name: "Unverified Notifications",
+			body: webhook.ResourceUpdateBody{Value: []webhook.ResourceUpdateNotification{
+				newNotification("sites/site123/lists/listA", listItemType),
+			}},
+			verify:      func(webhook.ResourceUpdateNotification) bool { return false },
+			expectError: true,
+		},
+		{
+			name:        "Empty Notifications",
+			body:        webhook.ResourceUpdateBody{},
+			expectError: true,
+		},
+		{
+			name: "Invalid Data Type",
+			body: webhook.ResourceUpdateBody{Value: []webhook.ResourceUpdateNotification{
+				newNotification("sites/site123/lists/listA", "invalidType"),
+			}},
+			expectError: true,
+		},
+		{
+			name: "Invalid Resource Format",
+			body: webhook.ResourceUpdateBody{Value: []webhook.ResourceUpdateNotification{
+				newNotification("invalid/resource/format", listItemType),
+			}},
+			expectError: true,
+		},
+		{
+			name: "Invalid Notifications Skipped",
+			body: webhook.ResourceUpdateBody{Value: []webhook.ResourceUpdateNotification{
+				newNotification("sites/site123/lists/listA", listItemType),
+				newNotification("invalid/resource/format", listItemType),
+				newNotification("sites/site123/lists/listB", "invalidType"),
+			}},
+			expected: []webhook.ListResource{{SiteID: "site123", ListID: "listA"}},
+		},
+		{
+			name: "Invalid And Unverified Notifications",
+			body: webhook.ResourceUpdateBody{Value: []webhook.ResourceUpdateNotification{
+				newNotification("sites/site123/lists/listA", listItemType),
+				newNotification("invalid/resource/format", listItemType),
+			}},
+			verify:      func(webhook.ResourceUpdateNotification) bool { return false },
+			expectError: true,
+			expectedErr: webhook.ErrUnverifiedNotifications,
+		},
 	}
 
 	for _, tt := range tests {
@@ -185,19 +249,45 @@ func TestExtractResourceUpdateData(t *testing.T) {
 			bodyBytes, _ := json.Marshal(tt.body)
 			req, _ := http.NewRequest(http.MethodPost, "/", bytes.NewBuffer(bodyBytes))
 
-			siteID, listID, err := webhook.ExtractResourceUpdateData(req)
+			verify := tt.verify
+			if verify == nil {
+				verify = verifyAll
+			}
+
+			resources, err := webhook.ExtractResourceUpdateData(req, verify)
 
 			if tt.expectError {
 				assert.Error(t, err)
+				if tt.expectedErr != nil {
+					assert.ErrorIs(t, err, tt.expectedErr)
+				}
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, "site123", siteID)
-				assert.Equal(t, "listA", listID)
+				assert.Equal(t, tt.expected, resources)
 			}
 		})
 	}
 }
 
+// TestResolveListReferences verifies that known resources resolve to lists and unknown ones are reported individually.
+func TestResolveListReferences(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+	setupTestResourcesYaml()
+
+	resources := []webhook.ListResource{
+		{SiteID: "site_id1", ListID: "list_id1"},
+		{SiteID: "site999", ListID: "listX"},
+		{SiteID: "site_id2", ListID: "list_id2"},
+	}
+
+	lists, unknown := webhook.ResolveListReferences(resources)
+
+	assert.Len(t, lists, 2)
+	assert.Equal(t, "list_id1", lists[0].ListID)
+	assert.Equal(t, "list_id2", lists[1].ListID)
+	assert.Equal(t, []webhook.ListResource{{SiteID: "site999", ListID: "listX"}}, unknown)
+}
+
 // TestParseSharepointResource tests the parsing of Sharepoint resource strings.
 func TestParseSharepointResource(t *testing.T) {
 	tests := []struct {
@@ -8,7 +8,9 @@ import (
 	"io"
 	"log/slog"
 	"math"
+	"microsoft-apps-exporter/internal/api"
 	"microsoft-apps-exporter/internal/api/webhook"
+	"microsoft-apps-exporter/internal/models"
 	"microsoft-apps-exporter/internal/sync"
 	"net/http"
 	"net/http/httptest"
@@ -18,12 +20,23 @@ import (
 )
 
 func TestNewSubscriptionHandler(t *testing.T) {
-	syncer := &sync.Syncer{}
 	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+	setupTestResourcesYaml()
+
+	syncer := sync.NewSyncer(&api.GraphHelper{}, nil)
+	syncer.Graph.TrackSubscription("sub-123", "sites/site_id1/lists/list_id1", "secret")
 	handler := webhook.NewSubscriptionHandler(syncer)
 
 	invalidPayload := []byte(`{"invalid": "data"}`)
 	missingSubIDPayload := []byte(`{"value": [{}]}`)
+	forgedPayload := []byte(`{"value": [{"subscriptionId": "sub-123", "clientState": "forged"}]}`)
+	missedPayload := []byte(`{"value": [
+		{"subscriptionId": "sub-123", "clientState": "secret", "lifecycleEvent": "missed"},
+		{"subscriptionId": "sub-123", "clientState": "forged", "lifecycleEvent": "missed", "resource": "sites/site_id2/lists/list_id2"}
+	]}`)
+	unknownResourcePayload := []byte(`{"value": [
+		{"subscriptionId": "sub-unknown", "clientState": "secret", "lifecycleEvent": "missed"}
+	]}`)
 
 	tests := []struct {
 		name           string
@@ -57,6 +70,24 @@ func TestNewSubscriptionHandler(t *testing.T) {
 			body:           bytes.NewReader(missingSubIDPayload),
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name:           "Client state mismatch",
+			method:         http.MethodPost,
+			body:           bytes.NewReader(forgedPayload),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Missed notifications",
+			method:         http.MethodPost,
+			body:           bytes.NewReader(missedPayload),
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "Notification of unknown subscription",
+			method:         http.MethodPost,
+			body:           bytes.NewReader(unknownResourcePayload),
+			expectedStatus: http.StatusForbidden,
+		},
 	}
 
 	for _, tt := range tests {
@@ -70,27 +101,86 @@ func TestNewSubscriptionHandler(t *testing.T) {
 			assert.Equal(t, tt.expectedStatus, w.Code)
 		})
 	}
+
+	assert.Equal(t, 1, syncer.Queue.Len(), "Only the verified missed notification should queue a resync")
+}
+
+// TestResolveLifecycleList verifies lifecycle notifications are mapped to configured lists.
+func TestResolveLifecycleList(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+	setupTestResourcesYaml()
+
+	syncer := &sync.Syncer{Graph: &api.GraphHelper{}}
+	syncer.Graph.TrackSubscription("sub-1", "sites/site_id1/lists/list_id1", "secret")
+	syncer.Graph.TrackSubscription("sub-2", "sites/site999/lists/listX", "secret")
+
+	tests := []struct {
+		name         string
+		notification webhook.SubscriptionLifecycleNotification
+		expected     models.ListReference
+		expectError  bool
+	}{
+		{
+			name:         "Resource in payload",
+			notification: webhook.SubscriptionLifecycleNotification{SubscriptionId: "sub-x", Resource: "sites/site_id2/lists/list_id2"},
+			expected:     models.ListReference{SiteID: "site_id2", ListID: "list_id2"},
+		},
+		{
+			name:         "Resource from registry",
+			notification: webhook.SubscriptionLifecycleNotification{SubscriptionId: "sub-1"},
+			expected:     models.ListReference{SiteID: "site_id1", ListID: "list_id1"},
+		},
+		{
+			name:         "Unknown subscription",
+			notification: webhook.SubscriptionLifecycleNotification{SubscriptionId: "sub-x"},
+			expectError:  true,
+		},
+		{
+			name:         "Resource not configured",
+			notification: webhook.SubscriptionLifecycleNotification{SubscriptionId: "sub-2"},
+			expectError:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := webhook.ResolveLifecycleList(syncer, tt.notification)
+
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected.SiteID, list.SiteID)
+				assert.Equal(t, tt.expected.ListID, list.ListID)
+			}
+		})
+	}
 }
 
 // TestExtractSubscriptionLifecycleData verifies the extraction of subscription lifecycle data from HTTP requests.
 func TestExtractSubscriptionLifecycleData(t *testing.T) {
 	t.Run("Valid payload", func(t *testing.T) {
 		payload := webhook.SubscriptionLifecycleBody{
-			Value: []struct {
-				SubscriptionId string `json:"subscriptionId"`
-			}{
-				{SubscriptionId: "sub-123"},
+			Value: []webhook.SubscriptionLifecycleNotification{
+				{SubscriptionId: "sub-123", ClientState: "secret", LifecycleEvent: "missed"},
+				{SubscriptionId: "sub-456", ClientState: "secret", LifecycleEvent: "subscriptionRemoved"},
 			},
 		}
 		body, _ := json.Marshal(payload)
 		r := httptest.NewRequest(http.MethodPost, "http://example.com", bytes.NewReader(body))
-		id, err := webhook.ExtractSubscriptionLifecycleData(r)
+		notifications, err := webhook.ExtractSubscriptionLifecycleData(r)
 
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
-		if id != "sub-123" {
-			t.Fatalf("expected subscriptionId 'sub-123', got '%s'", id)
+		if len(notifications) != 2 {
+			t.Fatalf("expected 2 notifications, got %d", len(notifications))
+		}
+		if notifications[0].SubscriptionId != "sub-123" || notifications[0].LifecycleEvent != "missed" {
+			t.Fatalf("unexpected first notification: %+v", notifications[0])
+		}
+		if notifications[1].SubscriptionId != "sub-456" || notifications[1].LifecycleEvent != "subscriptionRemoved" {
+			t.Fatalf("unexpected second notification: %+v", notifications[1])
 		}
 	})
 
@@ -105,9 +195,7 @@ func TestExtractSubscriptionLifecycleData(t *testing.T) {
 
 	t.Run("Missing", func(t *testing.T) {
 		payload := webhook.SubscriptionLifecycleBody{
-			Value: []struct {
-				SubscriptionId string `json:"subscriptionId"`
-			}{
+			Value: []webhook.SubscriptionLifecycleNotification{
 				{},
 			},
 		}
@@ -7,8 +7,10 @@ import (
 	"math"
 	"os"
 	"testing"
+	"time"
 
 	"microsoft-apps-exporter/internal/configuration"
+	"microsoft-apps-exporter/internal/models"
 
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -66,6 +68,28 @@ func TestUnmarshalConfig(t *testing.T) {
 	validateSharepointResource(t, config)
 }
 
+// TestValidate verifies the transforms, the filter and the time zone of every list are validated.
+func TestValidate(t *testing.T) {
+	config := configuration.Configuration{Sharepoint: &models.SharepointResource{Lists: []models.ListReference{
+		{ListID: "list-001", Transforms: map[string]string{"gp_email": "trim | lower"}},
+		{ListID: "list-002"},
+	}}}
+	assert.NoError(t, config.Validate())
+
+	config.Sharepoint.Lists[1].Transforms = map[string]string{"gp_email": "trim | lowercase"}
+	assert.ErrorContains(t, config.Validate(), "list-002")
+
+	config.Sharepoint.Lists[1].Transforms = nil
+	config.Sharepoint.Lists[1].Filter = []string{"Status within [Approved]"}
+	assert.ErrorContains(t, config.Validate(), "list-002")
+
+	config.Sharepoint.Lists[1].Filter = nil
+	config.Sharepoint.Lists[1].TimeZone = "Europe/Nowhere"
+	assert.ErrorContains(t, config.Validate(), "list-002")
+
+	assert.NoError(t, configuration.Configuration{}.Validate(), "No resources should be valid")
+}
+
 // TestGetConfig verifies correct environment variables loading.
 func TestGetConfig(t *testing.T) {
 	setupTestEnv()
@@ -96,12 +120,16 @@ func validateSharepointResource(t *testing.T, config configuration.Configuration
 		assert.NotEmpty(t, list.ListID, "list_id is required")
 		assert.NotEmpty(t, list.DbTableName, "table_name is required")
 		assert.NotEmpty(t, list.ColumnsMap, "columns_map must have at least one key-value pair")
+		for _, predicate := range list.Filter {
+			assert.NotEmpty(t, predicate, "filter predicate cannot be empty")
+		}
 
 		for key, value := range list.ColumnsMap {
 			assert.NotEmpty(t, key, "columns_map key cannot be empty")
 			assert.NotEmpty(t, value, "columns_map value cannot be empty")
 		}
 	}
+	assert.NoError(t, config.Validate(), "Resources should be valid")
 }
 
 // validateEnv ensures all required configuration fields are set.
@@ -143,3 +171,50 @@ func TestConfigSingleton(t *testing.T) {
 
 	assert.Equal(t, &config1, &config2, "GetConfig should return a singleton instance")
 }
+
+// TestGetEnvDuration verifies duration parsing with fallback to the default value.
+func TestGetEnvDuration(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{"Unset", "", time.Hour},
+		{"Valid", "15m", 15 * time.Minute},
+		{"Disabled", "0", 0},
+		{"Invalid", "soon", time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_DURATION", tt.value)
+			assert.Equal(t, tt.expected, configuration.GetEnvDuration("TEST_DURATION", time.Hour))
+		})
+	}
+}
+
+// TestGetEnvBool verifies boolean parsing with fallback to the default value.
+func TestGetEnvBool(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	tests := []struct {
+		name     string
+		value    string
+		expected bool
+	}{
+		{"Unset", "", true},
+		{"True", "true", true},
+		{"False", "false", false},
+		{"Numeric", "0", false},
+		{"Invalid", "maybe", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_BOOL", tt.value)
+			assert.Equal(t, tt.expected, configuration.GetEnvBool("TEST_BOOL", true))
+		})
+	}
+}
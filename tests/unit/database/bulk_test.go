@@ -0,0 +1,25 @@
+//go:build testing && unit
+
+package database_test
+
+import (
+	"microsoft-apps-exporter/internal/database"
+	"microsoft-apps-exporter/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLastOccurrences verifies repeated items keep their last occurrence and the order is preserved.
+func TestLastOccurrences(t *testing.T) {
+	item := func(id, etag string) models.ListItem {
+		return models.ListItem{Metadata: models.ListItemMetadata{ID: id, ETag: etag}}
+	}
+
+	unique := []models.ListItem{item("1", "A"), item("2", "B")}
+	assert.Equal(t, unique, database.LastOccurrences(unique), "Items without repetitions should be kept as is")
+
+	repeated := []models.ListItem{item("1", "A"), item("2", "B"), item("1", "C"), item("3", "D")}
+	expected := []models.ListItem{item("2", "B"), item("1", "C"), item("3", "D")}
+	assert.Equal(t, expected, database.LastOccurrences(repeated))
+}
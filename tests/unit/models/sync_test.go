@@ -0,0 +1,22 @@
+//go:build testing && unit
+
+package models_test
+
+import (
+	"testing"
+
+	"microsoft-apps-exporter/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSyncFailure_ClearedBy verifies a failed full resync is only cleared by a successful full sync.
+func TestSyncFailure_ClearedBy(t *testing.T) {
+	deltaFailure := models.SyncFailure{SiteID: "site-001", ListID: "list-001"}
+	fullFailure := models.SyncFailure{SiteID: "site-001", ListID: "list-001", Full: true}
+
+	assert.True(t, deltaFailure.ClearedBy(false), "A delta success should clear a failed delta sync")
+	assert.True(t, deltaFailure.ClearedBy(true), "A full success should clear a failed delta sync")
+	assert.False(t, fullFailure.ClearedBy(false), "A delta success should keep a failed full resync")
+	assert.True(t, fullFailure.ClearedBy(true), "A full success should clear a failed full resync")
+}
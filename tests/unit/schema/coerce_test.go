@@ -0,0 +1,124 @@
+//go:build testing && unit
+
+package schema_test
+
+import (
+	"testing"
+	"time"
+
+	"microsoft-apps-exporter/internal/models"
+	"microsoft-apps-exporter/internal/schema"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCoerceValue tests the values deserialized from JSON are converted to the values of their column types.
+func TestCoerceValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		column   models.ColumnDefinition
+		value    any
+		expected any
+	}{
+		{"Nil", models.ColumnDefinition{Type: models.ColumnTypeNumber}, nil, nil},
+		{"Text", models.ColumnDefinition{Type: models.ColumnTypeText}, "Jane", "Jane"},
+		{"Number as text", models.ColumnDefinition{Type: models.ColumnTypeText}, 42.0, "42"},
+		{"Number", models.ColumnDefinition{Type: models.ColumnTypeNumber}, 4.5, 4.5},
+		{"Number from string", models.ColumnDefinition{Type: models.ColumnTypeNumber}, " 4.5 ", 4.5},
+		{"Empty number", models.ColumnDefinition{Type: models.ColumnTypeNumber}, "", nil},
+		{"Currency", models.ColumnDefinition{Type: models.ColumnTypeCurrency}, 10.25, 10.25},
+		{"Boolean", models.ColumnDefinition{Type: models.ColumnTypeBoolean}, true, true},
+		{"Boolean from string", models.ColumnDefinition{Type: models.ColumnTypeBoolean}, "false", false},
+		{"Date and time", models.ColumnDefinition{Type: models.ColumnTypeDateTime}, "2025-10-17T08:30:00Z",
+			time.Date(2025, 10, 17, 8, 30, 0, 0, time.UTC)},
+		{"Date", models.ColumnDefinition{Type: models.ColumnTypeDate}, "2025-10-17T00:00:00Z", "2025-10-17"},
+		{"Choice", models.ColumnDefinition{Type: models.ColumnTypeChoice}, "High", "High"},
+		{"Multiple choices", models.ColumnDefinition{Type: models.ColumnTypeChoice, Multiple: true},
+			[]any{"High", "Low"}, `["High","Low"]`},
+		{"Lookup", models.ColumnDefinition{Type: models.ColumnTypeLookup},
+			map[string]any{"LookupId": 1.0, "LookupValue": "Paris"}, "Paris"},
+		{"Lookup without value", models.ColumnDefinition{Type: models.ColumnTypeLookup},
+			map[string]any{"LookupId": 1.0}, "1"},
+		{"Lookup as text", models.ColumnDefinition{Type: models.ColumnTypeLookup}, "Paris", "Paris"},
+		{"Multiple lookups", models.ColumnDefinition{Type: models.ColumnTypeLookup, Multiple: true},
+			[]any{map[string]any{"LookupId": 1.0, "LookupValue": "Paris"}, map[string]any{"LookupId": 2.0, "LookupValue": "Lyon"}},
+			`["Paris","Lyon"]`},
+		{"Person", models.ColumnDefinition{Type: models.ColumnTypePersonOrGroup},
+			map[string]any{"LookupId": 7.0, "LookupValue": "Jane Doe", "Email": "jane@example.com"}, "jane@example.com"},
+		{"Person without email", models.ColumnDefinition{Type: models.ColumnTypePersonOrGroup},
+			map[string]any{"LookupId": 7.0, "LookupValue": "Jane Doe", "Email": ""}, "Jane Doe"},
+		{"Person display name", models.ColumnDefinition{Type: models.ColumnTypePersonOrGroup},
+			map[string]any{"displayName": "Jane Doe"}, "Jane Doe"},
+		{"Multiple persons", models.ColumnDefinition{Type: models.ColumnTypePersonOrGroup, Multiple: true},
+			[]any{map[string]any{"LookupId": 7.0, "Email": "jane@example.com"}, map[string]any{"LookupValue": "HR Team"}},
+			`["jane@example.com","HR Team"]`},
+		{"Unknown lookup object", models.ColumnDefinition{Type: models.ColumnTypeLookup},
+			map[string]any{"Other": 1.0}, `{"Other":1}`},
+		{"Hyperlink", models.ColumnDefinition{Type: models.ColumnTypeHyperlinkOrPicture},
+			map[string]any{"Url": "https://example.com", "Description": "Example"}, `{"Description":"Example","Url":"https://example.com"}`},
+		{"Managed metadata", models.ColumnDefinition{Type: models.ColumnTypeTerm},
+			map[string]any{"Label": "Finance", "TermGuid": "a1"}, `{"Label":"Finance","TermGuid":"a1"}`},
+		{"Unknown scalar", models.ColumnDefinition{}, 42.0, 42.0},
+		{"Unknown object", models.ColumnDefinition{}, map[string]any{"a": 1.0}, `{"a":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := schema.CoerceValue(tt.column, tt.value, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+
+	t.Run("Date in the site time zone", func(t *testing.T) {
+		paris, err := time.LoadLocation("Europe/Paris")
+		require.NoError(t, err)
+		date := models.ColumnDefinition{Type: models.ColumnTypeDate}
+
+		// Midnight in Paris is the previous day in UTC
+		value, err := schema.CoerceValue(date, "2025-10-16T22:00:00Z", paris)
+		require.NoError(t, err)
+		assert.Equal(t, "2025-10-17", value)
+
+		value, err = schema.CoerceValue(date, "2025-10-17T00:00:00+02:00", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "2025-10-17", value, "Without time zone the offset of the value should be kept")
+
+		value, err = schema.CoerceValue(date, "2025-10-17T00:00:00+02:00", time.UTC)
+		require.NoError(t, err)
+		assert.Equal(t, "2025-10-16", value)
+	})
+
+	t.Run("Invalid values", func(t *testing.T) {
+		_, err := schema.CoerceValue(models.ColumnDefinition{Type: models.ColumnTypeNumber}, "many", nil)
+		assert.Error(t, err)
+		_, err = schema.CoerceValue(models.ColumnDefinition{Type: models.ColumnTypeBoolean}, []any{true}, nil)
+		assert.Error(t, err)
+		_, err = schema.CoerceValue(models.ColumnDefinition{Type: models.ColumnTypeDateTime}, "yesterday", nil)
+		assert.Error(t, err)
+	})
+}
+
+// TestFieldCoercer tests the fields of an item are converted in place, the invalid ones being cleared.
+func TestFieldCoercer(t *testing.T) {
+	coercer := schema.NewFieldCoercer([]models.ColumnDefinition{
+		{Name: "AvgScore", Type: models.ColumnTypeNumber},
+		{Name: "Reviewed", Type: models.ColumnTypeDateTime},
+	}, nil)
+	fields := models.ListItemMappedFields{
+		"AvgScore": "4.5",
+		"Reviewed": "not a date",
+		"Tags":     []any{"a", "b"},
+		"Title":    "Review",
+	}
+
+	err := coercer.Coerce(fields)
+	assert.ErrorContains(t, err, "Reviewed")
+	assert.Equal(t, models.ListItemMappedFields{
+		"AvgScore": 4.5,
+		"Reviewed": nil,
+		"Tags":     `["a","b"]`,
+		"Title":    "Review",
+	}, fields)
+}
@@ -0,0 +1,136 @@
+//go:build testing && unit
+
+package schema_test
+
+import (
+	"testing"
+
+	"microsoft-apps-exporter/internal/models"
+	"microsoft-apps-exporter/internal/schema"
+	"microsoft-apps-exporter/internal/transform"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPipeline builds the pipeline of the list, whose transforms are expected to be valid.
+func newPipeline(t *testing.T, list models.ListReference) *transform.Pipeline {
+	t.Helper()
+	pipeline, err := transform.NewPipeline(list)
+	require.NoError(t, err)
+	return pipeline
+}
+
+// TestParseDriftPolicy tests the known policies are parsed case insensitively.
+func TestParseDriftPolicy(t *testing.T) {
+	policy, err := schema.ParseDriftPolicy("Migrate")
+	require.NoError(t, err)
+	assert.Equal(t, schema.DriftPolicyMigrate, policy)
+
+	_, err = schema.ParseDriftPolicy("ignore")
+	assert.Error(t, err)
+}
+
+// TestDetectDrift tests the mismatches between the columns map, the SharePoint columns and the table columns.
+func TestDetectDrift(t *testing.T) {
+	list := models.ListReference{
+		ListID:      "list-001",
+		DbTableName: "evaluations_lv",
+		ColumnsMap: map[string]string{
+			"gp_avg_score": "AvgScore",
+			"gp_nickname":  "Nickname",
+			"gp_hrid":      "HRID",
+			"gp_comment":   "Comment",
+			"gp_reviewed":  "Reviewed",
+		},
+	}
+	columns := []models.ColumnDefinition{
+		{Name: "AvgScore", DisplayName: "Average score", Type: models.ColumnTypeNumber},
+		{Name: "Nickname", DisplayName: "Nickname", Type: models.ColumnTypeText, MaxLength: 20},
+		{Name: "EmployeeID", DisplayName: "HRID", Type: models.ColumnTypeText},
+		{Name: "Reviewed", DisplayName: "Reviewed", Type: models.ColumnTypeBoolean},
+	}
+	tableColumns := map[string]string{
+		"id":           "character varying",
+		"list_id":      "character varying",
+		"site_id":      "character varying",
+		"etag":         "character varying",
+		"gp_avg_score": "real",
+		"gp_hrid":      "character varying",
+		"gp_reviewed":  "integer",
+	}
+
+	t.Run("Drifts", func(t *testing.T) {
+		drifts := schema.DetectDrift(list, newPipeline(t, list), columns, tableColumns)
+		assert.Equal(t, []schema.Drift{
+			{Kind: schema.DriftMissingField, DbColumn: "gp_comment", Field: "Comment"},
+			{Kind: schema.DriftRenamedField, DbColumn: "gp_hrid", Field: "HRID", Expected: "EmployeeID"},
+			{Kind: schema.DriftMissingColumn, DbColumn: "gp_nickname", Field: "Nickname", Expected: "VARCHAR(20)"},
+			{Kind: schema.DriftTypeMismatch, DbColumn: "gp_reviewed", Field: "Reviewed", Expected: "BOOLEAN", Actual: "integer"},
+		}, drifts)
+		assert.True(t, drifts[2].Fixable())
+		assert.False(t, drifts[3].Fixable())
+	})
+
+	t.Run("Missing table", func(t *testing.T) {
+		drifts := schema.DetectDrift(list, newPipeline(t, list), columns, nil)
+		require.Len(t, drifts, 3)
+		assert.Equal(t, schema.DriftMissingTable, drifts[0].Kind)
+		assert.True(t, drifts[0].Fixable())
+	})
+
+	t.Run("Transformed column", func(t *testing.T) {
+		list := list
+		list.Transforms = map[string]string{"gp_reviewed": "number"}
+		for _, drift := range schema.DetectDrift(list, newPipeline(t, list), columns, tableColumns) {
+			assert.NotEqual(t, schema.DriftTypeMismatch, drift.Kind, "The type of a transformed column is not checked")
+		}
+	})
+
+	t.Run("Computed columns", func(t *testing.T) {
+		list := models.ListReference{
+			ColumnsMap: map[string]string{"gp_avg_score": "AvgScore", "gp_nickname": "Nickname"},
+			Transforms: map[string]string{
+				"gp_nickname":  "trim | lower",
+				"gp_full_name": `concat(FirstName, " ", LastName)`,
+				"gp_score":     "field(Score) | number",
+				"gp_hrid":      "field(HRID)",
+			},
+		}
+		tableColumns := map[string]string{"gp_avg_score": "double precision", "gp_hrid": "text"}
+
+		drifts := schema.DetectDrift(list, newPipeline(t, list), columns, tableColumns)
+		assert.Equal(t, []schema.Drift{
+			{Kind: schema.DriftMissingColumn, DbColumn: "gp_full_name", Expected: "TEXT"},
+			{Kind: schema.DriftMissingColumn, DbColumn: "gp_nickname", Field: "Nickname", Expected: "TEXT"},
+			{Kind: schema.DriftMissingColumn, DbColumn: "gp_score", Expected: "DOUBLE PRECISION"},
+		}, drifts, "Computed columns should be expected in the table with the type of their transform")
+		assert.True(t, drifts[0].Fixable())
+		assert.Equal(t, "column gp_full_name computed by its transform does not exist, expected TEXT", drifts[0].String())
+	})
+
+	t.Run("Compatible types", func(t *testing.T) {
+		list := models.ListReference{ColumnsMap: map[string]string{
+			"gp_nickname": "Nickname",
+			"gp_score":    "AvgScore",
+			"gp_birthday": "Birthday",
+		}}
+		columns := []models.ColumnDefinition{
+			{Name: "Nickname", Type: models.ColumnTypeText, MaxLength: 20},
+			{Name: "AvgScore", Type: models.ColumnTypeNumber},
+			{Name: "Birthday", Type: models.ColumnTypeDate},
+		}
+		tableColumns := map[string]string{
+			"gp_nickname": "text",
+			"gp_score":    "character varying",
+			"gp_birthday": "timestamp with time zone",
+		}
+		assert.Empty(t, schema.DetectDrift(list, newPipeline(t, list), columns, tableColumns))
+	})
+}
+
+// TestAddColumnStatement tests the DDL adding a missing column.
+func TestAddColumnStatement(t *testing.T) {
+	assert.Equal(t, "ALTER TABLE evaluations_lv ADD COLUMN IF NOT EXISTS gp_nickname VARCHAR(20);",
+		schema.AddColumnStatement("evaluations_lv", schema.Column{Name: "gp_nickname", Type: "VARCHAR(20)"}))
+}
@@ -0,0 +1,132 @@
+//go:build testing && unit
+
+package schema_test
+
+import (
+	"testing"
+	"time"
+
+	"microsoft-apps-exporter/internal/models"
+	"microsoft-apps-exporter/internal/schema"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPostgresType tests the mapping of SharePoint column types to PostgreSQL types.
+func TestPostgresType(t *testing.T) {
+	tests := []struct {
+		name     string
+		column   models.ColumnDefinition
+		expected string
+	}{
+		{"Bounded text", models.ColumnDefinition{Type: models.ColumnTypeText, MaxLength: 20}, "VARCHAR(20)"},
+		{"Unbounded text", models.ColumnDefinition{Type: models.ColumnTypeText}, "TEXT"},
+		{"Note", models.ColumnDefinition{Type: models.ColumnTypeNote}, "TEXT"},
+		{"Number", models.ColumnDefinition{Type: models.ColumnTypeNumber}, "DOUBLE PRECISION"},
+		{"Currency", models.ColumnDefinition{Type: models.ColumnTypeCurrency}, "NUMERIC(19, 4)"},
+		{"Boolean", models.ColumnDefinition{Type: models.ColumnTypeBoolean}, "BOOLEAN"},
+		{"Date and time", models.ColumnDefinition{Type: models.ColumnTypeDateTime}, "TIMESTAMPTZ"},
+		{"Date", models.ColumnDefinition{Type: models.ColumnTypeDate}, "DATE"},
+		{"Choice", models.ColumnDefinition{Type: models.ColumnTypeChoice}, "TEXT"},
+		{"Multiple choices", models.ColumnDefinition{Type: models.ColumnTypeChoice, Multiple: true}, "JSONB"},
+		{"Lookup", models.ColumnDefinition{Type: models.ColumnTypeLookup}, "TEXT"},
+		{"Hyperlink", models.ColumnDefinition{Type: models.ColumnTypeHyperlinkOrPicture}, "JSONB"},
+		{"Unknown", models.ColumnDefinition{Type: "unknown"}, "TEXT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, schema.PostgresType(tt.column))
+		})
+	}
+}
+
+// TestBuildTable tests the table of a list maps every configured column after the metadata columns.
+func TestBuildTable(t *testing.T) {
+	list := models.ListReference{
+		ListID:      "list-001",
+		DbTableName: "evaluations_lv",
+		ColumnsMap: map[string]string{
+			"gp_nickname":  "Nickname",
+			"gp_avg_score": "AvgScore",
+		},
+	}
+	columns := []models.ColumnDefinition{
+		{Name: "AvgScore", Type: models.ColumnTypeNumber},
+		{Name: "Nickname", Type: models.ColumnTypeText, MaxLength: 20},
+		{Name: "Title", Type: models.ColumnTypeText},
+	}
+
+	t.Run("Mapped columns", func(t *testing.T) {
+		table, err := schema.BuildTable(list, newPipeline(t, list), columns)
+		require.NoError(t, err)
+		assert.Equal(t, "evaluations_lv", table.Name)
+
+		names := make([]string, len(table.Columns))
+		for i, column := range table.Columns {
+			names[i] = column.Name
+		}
+		assert.Equal(t, []string{"id", "list_id", "site_id", "etag", "gp_avg_score", "gp_nickname"}, names)
+		assert.Equal(t, schema.Column{Name: "gp_nickname", Type: "VARCHAR(20)", Source: "Nickname"}, table.Columns[5])
+	})
+
+	t.Run("Transformed and computed columns", func(t *testing.T) {
+		list := list
+		list.Transforms = map[string]string{
+			"gp_nickname":  "trim | lower",
+			"gp_full_name": `concat(FirstName, " ", LastName)`,
+		}
+		table, err := schema.BuildTable(list, newPipeline(t, list), columns)
+		require.NoError(t, err)
+		assert.Equal(t, []schema.Column{
+			{Name: "gp_avg_score", Type: "DOUBLE PRECISION", Source: "AvgScore"},
+			{Name: "gp_full_name", Type: "TEXT"},
+			{Name: "gp_nickname", Type: "TEXT", Source: "Nickname"},
+		}, table.Columns[4:])
+	})
+
+	t.Run("Missing column", func(t *testing.T) {
+		_, err := schema.BuildTable(list, newPipeline(t, list), columns[:1])
+		assert.ErrorContains(t, err, "Nickname")
+	})
+
+	t.Run("Missing table", func(t *testing.T) {
+		list := models.ListReference{ListID: "list-001"}
+		_, err := schema.BuildTable(list, newPipeline(t, list), columns)
+		assert.Error(t, err)
+	})
+}
+
+// TestTableMigration tests the generated migration follows the goose format of the repository.
+func TestTableMigration(t *testing.T) {
+	list := models.ListReference{
+		ListID:      "list-001",
+		DbTableName: "evaluations_lv",
+		ColumnsMap:  map[string]string{"gp_avg_score": "AvgScore"},
+	}
+	table, err := schema.BuildTable(list, newPipeline(t, list), []models.ColumnDefinition{{Name: "AvgScore", Type: models.ColumnTypeNumber}})
+	require.NoError(t, err)
+
+	expected := `-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS evaluations_lv (
+    id           VARCHAR(40)      NOT NULL PRIMARY KEY,
+    list_id      VARCHAR(40)      NOT NULL,
+    site_id      VARCHAR(100)     NOT NULL,
+    etag         VARCHAR(45)      NOT NULL,
+    gp_avg_score DOUBLE PRECISION,
+    FOREIGN KEY (list_id) REFERENCES sharepoint_lists(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE evaluations_lv;
+-- +goose StatementEnd
+`
+	assert.Equal(t, expected, table.Migration())
+
+	now := time.Date(2025, 10, 17, 18, 0, 0, 0, time.UTC)
+	assert.Equal(t, "20251017180000_create_evaluations_lv.sql", table.MigrationFileName(now))
+}
@@ -0,0 +1,67 @@
+//go:build testing && unit
+
+package sync_test
+
+import (
+	"fmt"
+	"microsoft-apps-exporter/internal/api"
+	"microsoft-apps-exporter/internal/configuration"
+	"microsoft-apps-exporter/internal/database"
+	"microsoft-apps-exporter/internal/models"
+	"microsoft-apps-exporter/internal/sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deltaPages returns the pages of a delta query enumerating the items, pageSize items per page.
+func deltaPages(items, pageSize int) []api.ListItemsPage {
+	var pages []api.ListItemsPage
+	for start := 0; start < items; start += pageSize {
+		var page api.ListItemsPage
+		for i := start; i < min(start+pageSize, items); i++ {
+			page.Items = append(page.Items, models.ListItem{Metadata: models.ListItemMetadata{ID: fmt.Sprint(i), ETag: "etag"}})
+		}
+		nextLink := fmt.Sprintf("next-link-%d", len(pages)+1)
+		page.NextLink = &nextLink
+		pages = append(pages, page)
+	}
+	deltaLink := "delta-link"
+	pages[len(pages)-1].NextLink, pages[len(pages)-1].DeltaLink = nil, &deltaLink
+	return pages
+}
+
+// TestSyncer_BatchPages verifies the default configuration batches the delta pages of about 200 items Graph returns
+// up to the bulk threshold, so the items of large syncs are loaded with COPY.
+func TestSyncer_BatchPages(t *testing.T) {
+	t.Setenv("DB_BULK_THRESHOLD", "")
+	configuration.ResetConfig()
+	t.Cleanup(configuration.ResetConfig)
+
+	syncer := sync.NewSyncer(nil, nil)
+	db := &database.Database{BulkThreshold: configuration.GetConfig().DB_BULK_THRESHOLD}
+
+	batches := syncer.BatchPages(deltaPages(2500, 200))
+	require.Len(t, batches, 3)
+	assert.Len(t, batches[0], 1000)
+	assert.Len(t, batches[1], 1000)
+	assert.Len(t, batches[2], 500, "The last page should be written along with the remaining ones")
+	assert.True(t, db.UseBulk(len(batches[0])), "Full batches should be loaded with COPY")
+	assert.True(t, db.UseBulk(len(batches[1])), "Full batches should be loaded with COPY")
+
+	total := 0
+	for _, batch := range batches {
+		total += len(batch)
+	}
+	assert.Equal(t, 2500, total, "Every item should be written once")
+}
+
+// TestSyncer_BatchPagesDisabled verifies every page is written on its own without bulk threshold.
+func TestSyncer_BatchPagesDisabled(t *testing.T) {
+	syncer := &sync.Syncer{}
+
+	batches := syncer.BatchPages(deltaPages(450, 200))
+	require.Len(t, batches, 3)
+	assert.Len(t, batches[2], 50)
+}
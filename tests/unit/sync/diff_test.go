@@ -42,12 +42,6 @@ func TestDiffFull(t *testing.T) {
 }
 
 func TestDiffDelta(t *testing.T) {
-	existing := []testRecord{
-		{"1", "A"},
-		{"2", "B"},
-		{"3", "C"},
-		{"4", "D"}, // Unchanged
-	}
 	changes := []testRecord{
 		{"1", ""},  // Deleted
 		{"2", "B"}, // Updated
@@ -55,9 +49,8 @@ func TestDiffDelta(t *testing.T) {
 		{"5", "F"}, // New
 	}
 
-	toInsert, toUpdate, toDelete := sync.DiffDelta(existing, changes, getID, getETag)
+	toUpsert, toDelete := sync.DiffDelta(changes, getID, getETag)
 
-	assert.ElementsMatch(t, []testRecord{{"5", "F"}}, toInsert, "Expected insert records mismatch")
-	assert.ElementsMatch(t, []testRecord{{"2", "B"}, {"3", "E"}}, toUpdate, "Expected update records mismatch")
+	assert.ElementsMatch(t, []testRecord{{"2", "B"}, {"3", "E"}, {"5", "F"}}, toUpsert, "Expected upsert records mismatch")
 	assert.ElementsMatch(t, []string{"1"}, toDelete, "Expected delete records mismatch")
 }
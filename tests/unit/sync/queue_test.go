@@ -0,0 +1,279 @@
+//go:build testing && unit
+
+package sync_test
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"microsoft-apps-exporter/internal/models"
+	"microsoft-apps-exporter/internal/sync"
+	stdsync "sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestSyncQueue_Coalesce verifies duplicate pending requests for the same list are merged.
+func TestSyncQueue_Coalesce(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	queue := sync.NewSyncQueue(func(context.Context, sync.SyncJob) error { return nil }, 1)
+	list := models.ListReference{SiteID: "site", ListID: "list"}
+
+	assert.True(t, queue.Enqueue(sync.SyncJob{List: list}), "First request should be queued")
+	assert.False(t, queue.Enqueue(sync.SyncJob{List: list}), "Duplicate request should be coalesced")
+	assert.True(t, queue.Enqueue(sync.SyncJob{List: models.ListReference{SiteID: "site", ListID: "other"}}), "Other list should be queued")
+	assert.Equal(t, 2, queue.Len())
+}
+
+// TestSyncQueue_CoalesceFull verifies a full resync request upgrades a pending delta sync.
+func TestSyncQueue_CoalesceFull(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	jobs := make(chan sync.SyncJob, 10)
+	queue := sync.NewSyncQueue(func(_ context.Context, job sync.SyncJob) error {
+		jobs <- job
+		return nil
+	}, 1)
+	list := models.ListReference{SiteID: "site", ListID: "list"}
+
+	queue.Enqueue(sync.SyncJob{List: list})
+	assert.False(t, queue.Enqueue(sync.SyncJob{List: list, Full: true}))
+	assert.False(t, queue.Enqueue(sync.SyncJob{List: list}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go queue.Run(ctx)
+
+	job := <-jobs
+	assert.True(t, job.Full, "Coalesced job should keep the full resync request")
+	assert.Equal(t, list, job.List)
+}
+
+// TestSyncQueue_CoalescePending verifies a resumed pending sync coalesced with another request stays pending.
+func TestSyncQueue_CoalescePending(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	jobs := make(chan sync.SyncJob, 10)
+	queue := sync.NewSyncQueue(func(_ context.Context, job sync.SyncJob) error {
+		jobs <- job
+		return nil
+	}, 1)
+	list := models.ListReference{SiteID: "site", ListID: "list"}
+
+	queue.Enqueue(sync.SyncJob{List: list, Trigger: sync.TriggerSchedule})
+	assert.False(t, queue.Enqueue(sync.SyncJob{List: list, Trigger: sync.TriggerWebhook, Pending: true}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go queue.Run(ctx)
+
+	job := <-jobs
+	assert.True(t, job.Pending, "Coalesced job should remove the stored pending sync")
+	assert.Equal(t, sync.TriggerSchedule, job.Trigger)
+}
+
+// TestSyncQueue_SingleInFlightPerList ensures a list never syncs concurrently with itself
+// and a request arriving mid-sync runs exactly once afterwards.
+func TestSyncQueue_SingleInFlightPerList(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	var running, maxRunning, runs atomic.Int32
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+
+	queue := sync.NewSyncQueue(func(context.Context, sync.SyncJob) error {
+		current := running.Add(1)
+		defer running.Add(-1)
+		if current > maxRunning.Load() {
+			maxRunning.Store(current)
+		}
+		runs.Add(1)
+		started <- struct{}{}
+		<-release
+		return nil
+	}, 4)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		queue.Run(ctx)
+		close(done)
+	}()
+
+	list := models.ListReference{SiteID: "site", ListID: "list"}
+	queue.Enqueue(sync.SyncJob{List: list})
+	<-started
+
+	// Requests arriving while the list is in flight collapse into one follow-up
+	assert.True(t, queue.Enqueue(sync.SyncJob{List: list}))
+	assert.False(t, queue.Enqueue(sync.SyncJob{List: list}))
+	assert.False(t, queue.EnqueueIdle(sync.SyncJob{List: list}), "Idle-only requests should skip busy lists")
+
+	release <- struct{}{}
+	<-started
+	release <- struct{}{}
+
+	assert.Eventually(t, func() bool { return queue.Len() == 0 && running.Load() == 0 }, time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	assert.Equal(t, int32(2), runs.Load(), "Expected initial sync plus one coalesced follow-up")
+	assert.Equal(t, int32(1), maxRunning.Load(), "List should never be synchronized concurrently")
+}
+
+// TestSyncQueue_MaxConcurrency ensures the total number of concurrent syncs is bounded.
+func TestSyncQueue_MaxConcurrency(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	const maxConcurrency = 2
+	var mu stdsync.Mutex
+	var running, maxRunning, runs int
+
+	queue := sync.NewSyncQueue(func(context.Context, sync.SyncJob) error {
+		mu.Lock()
+		running++
+		runs++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	}, maxConcurrency)
+
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		queue.Enqueue(sync.SyncJob{List: models.ListReference{SiteID: "site", ListID: id}})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		queue.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return runs == 5 && running == 0
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	assert.LessOrEqual(t, maxRunning, maxConcurrency)
+}
+
+// TestSyncQueue_CancelsRunningSync ensures cancelling the queue cancels the context of in-flight syncs.
+func TestSyncQueue_CancelsRunningSync(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	started := make(chan struct{})
+	cancelled := make(chan error, 1)
+	queue := sync.NewSyncQueue(func(ctx context.Context, _ sync.SyncJob) error {
+		close(started)
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+		return ctx.Err()
+	}, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		queue.Run(ctx)
+		close(done)
+	}()
+
+	queue.Enqueue(sync.SyncJob{List: models.ListReference{SiteID: "site", ListID: "list"}})
+	<-started
+	cancel()
+
+	select {
+	case err := <-cancelled:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("In-flight sync was not cancelled")
+	}
+	<-done
+}
+
+// TestSyncer_EnqueueSyncPropagatesSpan verifies the span of the request is handed over to the queued job.
+func TestSyncer_EnqueueSyncPropagatesSpan(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	jobs := make(chan sync.SyncJob, 1)
+	syncer := &sync.Syncer{}
+	syncer.Queue = sync.NewSyncQueue(func(_ context.Context, job sync.SyncJob) error {
+		jobs <- job
+		return nil
+	}, 1)
+
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx, cancel := context.WithCancel(trace.ContextWithSpanContext(context.Background(), spanContext))
+	defer cancel()
+
+	list := models.ListReference{SiteID: "site", ListID: "list"}
+	assert.True(t, syncer.EnqueueSync(ctx, list, sync.TriggerWebhook))
+	go syncer.Queue.Run(ctx)
+
+	job := <-jobs
+	assert.Equal(t, spanContext, job.SpanContext)
+	assert.Equal(t, sync.TriggerWebhook, job.Trigger)
+}
+
+// TestSyncQueue_Close verifies a closed queue rejects requests, hands back the pending ones
+// and lets the running sync finish before Run returns.
+func TestSyncQueue_Close(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	var runs atomic.Int32
+	queue := sync.NewSyncQueue(func(context.Context, sync.SyncJob) error {
+		runs.Add(1)
+		started <- struct{}{}
+		<-release
+		return nil
+	}, 1)
+
+	running := models.ListReference{SiteID: "site", ListID: "running"}
+	waiting := models.ListReference{SiteID: "site", ListID: "waiting"}
+	queue.Enqueue(sync.SyncJob{List: running})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go queue.Run(ctx)
+	<-started
+
+	queue.Enqueue(sync.SyncJob{List: waiting, Full: true})
+	pending := queue.Close()
+	assert.Equal(t, []sync.SyncJob{{List: waiting, Full: true}}, pending, "Pending request should be handed back")
+	assert.True(t, queue.Closed())
+	assert.False(t, queue.Enqueue(sync.SyncJob{List: waiting}), "Closed queue should reject requests")
+	assert.Nil(t, queue.Close(), "Closing twice should not hand back anything")
+
+	select {
+	case <-queue.Stopped():
+		t.Fatal("Run returned before the running sync finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-queue.Stopped():
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after the running sync finished")
+	}
+	assert.Equal(t, int32(1), runs.Load(), "Pending request should not run after Close")
+}
@@ -0,0 +1,38 @@
+//go:build testing && unit
+
+package sync_test
+
+import (
+	"microsoft-apps-exporter/internal/sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRetrier_NextAttempt verifies retries back off exponentially up to the maximum delay.
+func TestRetrier_NextAttempt(t *testing.T) {
+	retrier := &sync.Retrier{BaseDelay: time.Minute, MaxDelay: 10 * time.Minute, MaxAttempts: 6}
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	upperBounds := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute}
+	for i, upper := range upperBounds {
+		next := retrier.NextAttempt(i+1, now)
+		require.NotNil(t, next, "Attempt %d should be retried", i+1)
+
+		delay := next.Sub(now)
+		assert.GreaterOrEqual(t, delay, upper/2, "Attempt %d delay should keep half of the backoff", i+1)
+		assert.LessOrEqual(t, delay, upper, "Attempt %d delay should not exceed the backoff", i+1)
+	}
+}
+
+// TestRetrier_NextAttemptExhausted ensures no retry is scheduled once the attempts are exhausted.
+func TestRetrier_NextAttemptExhausted(t *testing.T) {
+	retrier := &sync.Retrier{BaseDelay: time.Minute, MaxDelay: time.Hour, MaxAttempts: 3}
+	now := time.Now()
+
+	assert.NotNil(t, retrier.NextAttempt(2, now))
+	assert.Nil(t, retrier.NextAttempt(3, now))
+	assert.Nil(t, retrier.NextAttempt(10, now))
+}
@@ -0,0 +1,30 @@
+//go:build testing && unit
+
+package sync_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"microsoft-apps-exporter/internal/models"
+	"microsoft-apps-exporter/internal/sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSyncer_RecordRunWithoutDatabase verifies a Syncer without a database completes the run without persisting it.
+func TestSyncer_RecordRunWithoutDatabase(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	syncer := &sync.Syncer{}
+	run := &models.SyncRun{SiteID: "site", ListID: "list", Trigger: string(sync.TriggerWebhook), StartedAt: time.Now()}
+
+	assert.NotPanics(t, func() { syncer.RecordRun(context.Background(), run, errors.New("sync failed")) })
+	assert.False(t, run.FinishedAt.IsZero(), "The run should be finished")
+	require.NotNil(t, run.Error)
+	assert.Equal(t, "sync failed", *run.Error)
+}
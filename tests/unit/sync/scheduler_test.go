@@ -0,0 +1,46 @@
+//go:build testing && unit
+
+package sync_test
+
+import (
+	"microsoft-apps-exporter/internal/models"
+	"microsoft-apps-exporter/internal/sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSchedulerListInterval verifies the per-list interval override falls back to the global default.
+func TestSchedulerListInterval(t *testing.T) {
+	scheduler := &sync.Scheduler{Interval: time.Hour}
+
+	tests := []struct {
+		name     string
+		list     models.ListReference
+		expected time.Duration
+	}{
+		{"Global default", models.ListReference{}, time.Hour},
+		{"List override", models.ListReference{SyncInterval: 10 * time.Minute}, 10 * time.Minute},
+		{"List disabled", models.ListReference{SyncInterval: -1}, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, scheduler.ListInterval(tt.list))
+		})
+	}
+}
+
+// TestSchedulerJitter ensures jitter stays within the configured bound.
+func TestSchedulerJitter(t *testing.T) {
+	scheduler := &sync.Scheduler{Jitter: time.Second}
+	for range 100 {
+		jitter := scheduler.NextJitter()
+		assert.GreaterOrEqual(t, jitter, time.Duration(0))
+		assert.Less(t, jitter, time.Second)
+	}
+
+	noJitter := &sync.Scheduler{}
+	assert.Zero(t, noJitter.NextJitter(), "Jitter should be disabled when not configured")
+}
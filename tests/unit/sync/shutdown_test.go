@@ -0,0 +1,130 @@
+//go:build testing && unit
+
+package sync_test
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"microsoft-apps-exporter/internal/configuration"
+	"microsoft-apps-exporter/internal/models"
+	"microsoft-apps-exporter/internal/sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBlockingSyncer creates a Syncer whose queued syncs block until released or cancelled.
+func newBlockingSyncer(started chan<- struct{}, release <-chan struct{}, result chan<- error) *sync.Syncer {
+	syncer := &sync.Syncer{}
+	syncer.Retrier = &sync.Retrier{Syncer: syncer}
+	syncer.Queue = sync.NewSyncQueue(func(ctx context.Context, _ sync.SyncJob) error {
+		started <- struct{}{}
+		select {
+		case <-release:
+			result <- nil
+			return nil
+		case <-ctx.Done():
+			result <- ctx.Err()
+			return ctx.Err()
+		}
+	}, 1)
+	return syncer
+}
+
+// TestSyncer_ShutdownDrains verifies a running sync outlives the main context and is awaited by Shutdown.
+func TestSyncer_ShutdownDrains(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	result := make(chan error, 1)
+	syncer := newBlockingSyncer(started, release, result)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	syncer.Start(ctx)
+	syncer.EnqueueSync(ctx, models.ListReference{SiteID: "site", ListID: "list"}, sync.TriggerWebhook)
+	<-started
+
+	cancel() // Termination signal
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), time.Second)
+	defer cancelShutdown()
+	syncer.Shutdown(shutdownCtx)
+
+	assert.NoError(t, <-result, "Running sync should finish within the grace period")
+	assert.False(t, syncer.EnqueueSync(context.Background(), models.ListReference{SiteID: "site", ListID: "other"},
+		sync.TriggerWebhook), "Syncs should be rejected after Shutdown")
+}
+
+// TestSyncer_ShutdownCancelsAfterGracePeriod verifies running syncs are cancelled once the grace period expires.
+func TestSyncer_ShutdownCancelsAfterGracePeriod(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	started := make(chan struct{}, 1)
+	result := make(chan error, 1)
+	syncer := newBlockingSyncer(started, make(chan struct{}), result)
+
+	syncer.Start(context.Background())
+	syncer.EnqueueSync(context.Background(), models.ListReference{SiteID: "site", ListID: "list"}, sync.TriggerWebhook)
+	<-started
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelShutdown()
+
+	done := make(chan struct{})
+	go func() {
+		syncer.Shutdown(shutdownCtx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown did not return after the grace period")
+	}
+	assert.ErrorIs(t, <-result, context.Canceled, "Running sync should be cancelled")
+}
+
+// TestSyncer_SyncResourcesQueues verifies the startup syncs run through the queue, coalesced with the resumed ones,
+// which keep removing their stored pending sync.
+func TestSyncer_SyncResourcesQueues(t *testing.T) {
+	slog.SetLogLoggerLevel(math.MaxInt) // Disable logging
+
+	configuration.ResetConfig()
+	viper.Reset()
+	viper.SetConfigName("resources")
+	viper.SetConfigType("yaml")
+	viper.AddConfigPath("..")
+	t.Cleanup(func() {
+		configuration.ResetConfig()
+		viper.Reset()
+	})
+
+	lists := configuration.GetConfig().Sharepoint.Lists
+	require.Len(t, lists, 2)
+
+	jobs := make(chan sync.SyncJob, 2)
+	syncer := &sync.Syncer{}
+	syncer.Queue = sync.NewSyncQueue(func(_ context.Context, job sync.SyncJob) error {
+		jobs <- job
+		return nil
+	}, 1)
+
+	// Resumed by ResumePending before the startup syncs
+	syncer.Queue.Enqueue(sync.SyncJob{List: lists[0], Full: true, Trigger: sync.TriggerWebhook, Pending: true})
+	syncer.SyncResources(context.Background())
+	assert.Equal(t, 2, syncer.Queue.Len(), "Every list should be queued once")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go syncer.Queue.Run(ctx)
+
+	resumed, startup := <-jobs, <-jobs
+	assert.Equal(t, sync.SyncJob{List: lists[0], Full: true, Trigger: sync.TriggerWebhook, Pending: true}, resumed)
+	assert.Equal(t, sync.SyncJob{List: lists[1], Trigger: sync.TriggerStartup}, startup)
+}
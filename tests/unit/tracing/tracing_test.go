@@ -0,0 +1,65 @@
+//go:build testing && unit
+
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"microsoft-apps-exporter/internal/tracing"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestStartEnd verifies spans are nested under the context span and failures are recorded.
+func TestStartEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	ctx, parent := tracing.Start(context.Background(), "parent")
+	_, child := tracing.Start(ctx, "child", attribute.String("list_id", "list"))
+	tracing.End(child, errors.New("boom"))
+	tracing.End(parent, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "child", spans[0].Name())
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Contains(t, spans[0].Attributes(), attribute.String("list_id", "list"))
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}
+
+// TestSetup_FileExporter verifies spans are written to the configured file on shutdown.
+func TestSetup_FileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.json")
+	t.Setenv("OTEL_TRACES_EXPORTER", tracing.ExporterFile)
+	t.Setenv("OTEL_TRACES_FILE", path)
+
+	previous := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(previous)
+
+	shutdown, err := tracing.Setup(context.Background())
+	require.NoError(t, err)
+
+	_, span := tracing.Start(context.Background(), "sync.sharepoint", attribute.String("site_id", "site"))
+	tracing.End(span, nil)
+	require.NoError(t, shutdown(context.Background()))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"Name":"sync.sharepoint"`)
+	assert.Contains(t, string(content), `"site_id"`)
+}
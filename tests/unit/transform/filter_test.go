@@ -0,0 +1,103 @@
+//go:build testing && unit
+
+package transform_test
+
+import (
+	"testing"
+
+	"microsoft-apps-exporter/internal/models"
+	"microsoft-apps-exporter/internal/transform"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFilterMatch tests the predicates on the fields of an item.
+func TestFilterMatch(t *testing.T) {
+	fields := models.ListItemMappedFields{
+		"Status":       "Approved",
+		"Score":        4.5,
+		"Count":        "12",
+		"Confidential": false,
+		"Due":          "2025-03-01T00:00:00Z",
+		"Tags":         []any{"hr", "finance"},
+		"Empty":        "",
+		"Missing":      nil,
+	}
+
+	tests := []struct {
+		name     string
+		source   string
+		expected bool
+	}{
+		{"In", "Status in [Approved, Closed]", true},
+		{"In is case insensitive", `Status in ["approved"]`, true},
+		{"Not in", "Status not in [Approved, Closed]", false},
+		{"Equal", "Status = Approved", true},
+		{"Not equal", "Status != Draft", true},
+		{"Number", "Score = 4.5", true},
+		{"Number from text", "Count = 12", true},
+		{"Boolean", "Confidential != true", true},
+		{"Greater", "Score > 4", true},
+		{"Less or equal", "Score <= 4", false},
+		{"Ordering of numbers from text", "Count >= 9", true},
+		{"Ordering of text", `Due < "2025-04-01"`, true},
+		{"Any of several values", "Tags = finance", true},
+		{"None of several values", "Tags not in [legal]", true},
+		{"Null", "Missing = null", true},
+		{"Empty text is null", "Empty in [null]", true},
+		{"Unknown field is null", "Unknown not in [null]", false},
+		{"Ordering of null", "Missing > 0", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := transform.ParseFilter([]string{tt.source})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, filter.Match(fields))
+		})
+	}
+
+	t.Run("Every predicate", func(t *testing.T) {
+		filter, err := transform.ParseFilter([]string{"Status = Approved", "Score > 5"})
+		require.NoError(t, err)
+		assert.False(t, filter.Match(fields))
+		assert.Equal(t, []string{"Status", "Score"}, filter.Fields())
+	})
+
+	t.Run("No predicate", func(t *testing.T) {
+		filter, err := transform.ParseFilter(nil)
+		require.NoError(t, err)
+		assert.True(t, filter.Match(fields))
+	})
+}
+
+// TestParseFilter tests invalid predicates are rejected.
+func TestParseFilter(t *testing.T) {
+	invalid := []string{
+		"",
+		"Status",
+		"Status Approved",
+		"Status in Approved",
+		"Status in [Approved",
+		"Status in [Approved Closed]",
+		"Status not [Approved]",
+		"Status ! Approved",
+		`Status == "Done"`,
+		"Score =< 2",
+		"Score >== 2",
+		"Status = Approved Closed",
+		"Score > null",
+		`"Status" = Approved`,
+	}
+	for _, source := range invalid {
+		_, err := transform.ParseFilter([]string{source})
+		assert.Error(t, err, "%q should be rejected", source)
+	}
+
+	_, err := transform.ParseFilter([]string{`Status == "Done"`})
+	assert.ErrorContains(t, err, "unknown operator ==")
+
+	_, err = transform.ParseFilter([]string{"Status = Approved", "Score >", "Tags in ["})
+	assert.ErrorContains(t, err, `"Score >"`)
+	assert.ErrorContains(t, err, `"Tags in ["`)
+}
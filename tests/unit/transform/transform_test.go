@@ -0,0 +1,153 @@
+//go:build testing && unit
+
+package transform_test
+
+import (
+	"testing"
+
+	"microsoft-apps-exporter/internal/models"
+	"microsoft-apps-exporter/internal/transform"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExpressionEval tests the built-in functions piped on the input value.
+func TestExpressionEval(t *testing.T) {
+	fields := models.ListItemMappedFields{
+		"FirstName": "Jane",
+		"LastName":  "Doe",
+		"Score":     " 4.5 ",
+		"Missing":   nil,
+	}
+
+	tests := []struct {
+		name     string
+		source   string
+		input    any
+		expected any
+	}{
+		{"Trim and lower", "trim | lower", "  Jane.Doe@Example.COM ", "jane.doe@example.com"},
+		{"Upper", "upper()", "hr-42", "HR-42"},
+		{"Null is kept", "trim | lower", nil, nil},
+		{"Number", "number", " 4.5 ", 4.5},
+		{"Empty number", "number | default(0)", "", 0.0},
+		{"Default", `default("n/a")`, nil, "n/a"},
+		{"Default keeps value", `default("n/a")`, "set", "set"},
+		{"Concat fields", `concat(FirstName, " ", LastName)`, nil, "Jane Doe"},
+		{"Concat skips null", `concat(Missing, "-", LastName)`, "Id", "Id-Doe"},
+		{"Replace", `replace(".", "")`, "a.b.c", "abc"},
+		{"Field", "field(Score) | number", nil, 4.5},
+		{"Number as text", "lower", 42.0, "42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := transform.Parse(tt.source)
+			require.NoError(t, err)
+
+			value, err := expr.Eval(tt.input, fields)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+
+	t.Run("Invalid number", func(t *testing.T) {
+		expr, err := transform.Parse("number")
+		require.NoError(t, err)
+		_, err = expr.Eval("many", fields)
+		assert.Error(t, err)
+	})
+}
+
+// TestParse tests invalid expressions are rejected with the referenced fields of valid ones known.
+func TestParse(t *testing.T) {
+	expr, err := transform.Parse(`concat(FirstName, " ", LastName) | trim`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"FirstName", "LastName"}, expr.Fields())
+
+	invalid := []string{
+		"",
+		"unknown",
+		"trim lower",
+		"trim |",
+		"default",
+		"replace(\"a\")",
+		"concat(\"unterminated)",
+		"concat(FirstName LastName)",
+		"trim | $",
+	}
+	for _, source := range invalid {
+		_, err := transform.Parse(source)
+		assert.Error(t, err, "%q should be rejected", source)
+	}
+}
+
+// TestPipeline tests the table columns are computed from the mapped fields and the transforms.
+func TestPipeline(t *testing.T) {
+	pipeline, err := transform.NewPipeline(models.ListReference{
+		ColumnsMap: map[string]string{
+			"gp_email": "Email",
+			"gp_hrid":  "HRID",
+			"gp_score": "Score",
+		},
+		Transforms: map[string]string{
+			"gp_email":     "trim | lower",
+			"gp_score":     "number",
+			"gp_full_name": `concat(FirstName, " ", LastName)`,
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Email", "FirstName", "HRID", "LastName", "Score"}, pipeline.Fields())
+	assert.Equal(t, map[string]string{
+		"gp_email":     "gp_email",
+		"gp_hrid":      "gp_hrid",
+		"gp_score":     "gp_score",
+		"gp_full_name": "gp_full_name",
+	}, pipeline.Columns())
+
+	assert.Equal(t, "TEXT", pipeline.Type("gp_email", "VARCHAR(255)"), "Text functions should return text")
+	assert.Equal(t, "DOUBLE PRECISION", pipeline.Type("gp_score", "TEXT"))
+	assert.Equal(t, "TEXT", pipeline.Type("gp_full_name", ""))
+	assert.Equal(t, "VARCHAR(10)", pipeline.Type("gp_hrid", "VARCHAR(10)"), "Columns without transform should keep their type")
+
+	values, err := pipeline.Apply(models.ListItemMappedFields{
+		"Email":     " Jane.Doe@Example.com",
+		"HRID":      "HR-42",
+		"Score":     "many",
+		"FirstName": "Jane",
+		"LastName":  "Doe",
+	})
+	assert.ErrorContains(t, err, "gp_score")
+	assert.Equal(t, models.ListItemMappedFields{
+		"gp_email":     "jane.doe@example.com",
+		"gp_hrid":      "HR-42",
+		"gp_score":     nil,
+		"gp_full_name": "Jane Doe",
+	}, values)
+
+	t.Run("Filter", func(t *testing.T) {
+		pipeline, err := transform.NewPipeline(models.ListReference{
+			ColumnsMap: map[string]string{"gp_email": "Email"},
+			Filter:     []string{"Status in [Approved, Closed]"},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"Email", "Status"}, pipeline.Fields())
+		assert.True(t, pipeline.Match(models.ListItemMappedFields{"Status": "Closed"}))
+		assert.False(t, pipeline.Match(models.ListItemMappedFields{"Status": "Draft"}))
+
+		_, err = transform.NewPipeline(models.ListReference{Filter: []string{"Status within [Approved]"}})
+		assert.ErrorContains(t, err, "Status within")
+	})
+
+	t.Run("Invalid transforms", func(t *testing.T) {
+		_, err := transform.NewPipeline(models.ListReference{Transforms: map[string]string{
+			"id":       "trim",
+			"gp_email": "lowercase",
+		}})
+		assert.ErrorContains(t, err, "column id")
+		assert.ErrorContains(t, err, "column gp_email")
+	})
+}